@@ -0,0 +1,250 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoAPFSVolume is returned when no APFS volume of an image matches the requested role or name.
+var ErrNoAPFSVolume = errors.New("no matching APFS volume")
+
+// APFSRole is the role of an APFS volume, as reported by diskutil apfs list.
+type APFSRole string
+
+const (
+	// APFSRoleSystem is the read-only system volume of a macOS installation.
+	APFSRoleSystem APFSRole = "System"
+	// APFSRoleData is the user data volume of a macOS installation.
+	APFSRoleData APFSRole = "Data"
+	// APFSRolePreboot is the preboot volume.
+	APFSRolePreboot APFSRole = "Preboot"
+	// APFSRoleRecovery is the recovery volume.
+	APFSRoleRecovery APFSRole = "Recovery"
+	// APFSRoleVM is the swap volume.
+	APFSRoleVM APFSRole = "VM"
+)
+
+// apfsRoleFlags is the diskutil apfs addVolume -role letter of the roles.
+var apfsRoleFlags = map[APFSRole]string{
+	APFSRoleSystem:   "S",
+	APFSRoleData:     "D",
+	APFSRolePreboot:  "B",
+	APFSRoleRecovery: "R",
+	APFSRoleVM:       "V",
+}
+
+// APFSContainer is an APFS container, as reported by diskutil apfs list.
+type APFSContainer struct {
+	// Reference is the synthesized disk of the container, such as disk5.
+	Reference string `plist:"ContainerReference"`
+
+	UUID            string `plist:"APFSContainerUUID"`
+	CapacityCeiling int64  `plist:"CapacityCeiling"`
+	CapacityFree    int64  `plist:"CapacityFree"`
+
+	// PhysicalStores is the partitions backing the container, such as disk4s1 for an APFS image attached as disk4.
+	PhysicalStores []APFSPhysicalStore `plist:"PhysicalStores"`
+
+	Volumes []APFSVolume `plist:"Volumes"`
+}
+
+// APFSPhysicalStore is a partition backing an APFS container.
+type APFSPhysicalStore struct {
+	DeviceIdentifier string `plist:"DeviceIdentifier"`
+	Size             int64  `plist:"Size"`
+}
+
+// APFSVolume is a volume of an APFS container.
+type APFSVolume struct {
+	// DeviceIdentifier is the disk of the volume, such as disk5s1.
+	DeviceIdentifier string `plist:"DeviceIdentifier"`
+
+	UUID          string     `plist:"APFSVolumeUUID"`
+	Name          string     `plist:"Name"`
+	Roles         []APFSRole `plist:"Roles"`
+	CapacityInUse int64      `plist:"CapacityInUse"`
+	Encryption    bool       `plist:"Encryption"`
+	FileVault     bool       `plist:"FileVault"`
+	Locked        bool       `plist:"Locked"`
+}
+
+// DeviceNode returns the device node path of v, such as /dev/disk5s1.
+func (v APFSVolume) DeviceNode() string { return "/dev/" + v.DeviceIdentifier }
+
+// HasRole reports whether v has role.
+func (v APFSVolume) HasRole(role APFSRole) bool {
+	for _, r := range v.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// apfsList is the diskutil apfs list -plist output.
+type apfsList struct {
+	Containers []APFSContainer `plist:"Containers"`
+}
+
+// APFSContainers returns the APFS containers of the attached image whose whole disk is deviceNode, such as /dev/disk4.
+// deviceNode may also be the synthesized disk of a container.
+//
+// An APFS image is attached as a whole disk holding the physical store partition,
+// while its volumes are slices of another synthesized disk, so the image device entries alone do not list them.
+func APFSContainers(deviceNode string) ([]APFSContainer, error) {
+	return DefaultClient.APFSContainers(deviceNode)
+}
+
+// APFSContainers is like the package-level APFSContainers, but runs diskutil with the configuration of c.
+func (c *Client) APFSContainers(deviceNode string) ([]APFSContainer, error) {
+	out, _, err := c.runTool(c.toolCommand("diskutil", "apfs", "list", "-plist"))
+	if err != nil {
+		return nil, err
+	}
+	var list apfsList
+	if err := unmarshalPlist(out, &list); err != nil {
+		return nil, err
+	}
+
+	disk := strings.TrimPrefix(wholeDiskNode(deviceNode), "/dev/")
+	var containers []APFSContainer
+	for _, ct := range list.Containers {
+		if ct.Reference == disk {
+			containers = append(containers, ct)
+			continue
+		}
+		for _, ps := range ct.PhysicalStores {
+			if strings.TrimPrefix(wholeDiskNode("/dev/"+ps.DeviceIdentifier), "/dev/") == disk {
+				containers = append(containers, ct)
+				break
+			}
+		}
+	}
+
+	return containers, nil
+}
+
+// APFSVolumes returns the volumes of all the APFS containers of the attached image whose whole disk is deviceNode.
+func APFSVolumes(deviceNode string) ([]APFSVolume, error) {
+	return DefaultClient.APFSVolumes(deviceNode)
+}
+
+// APFSVolumes is like the package-level APFSVolumes, but runs diskutil with the configuration of c.
+func (c *Client) APFSVolumes(deviceNode string) ([]APFSVolume, error) {
+	containers, err := c.APFSContainers(deviceNode)
+	if err != nil {
+		return nil, err
+	}
+	var volumes []APFSVolume
+	for _, ct := range containers {
+		volumes = append(volumes, ct.Volumes...)
+	}
+	return volumes, nil
+}
+
+// APFSVolumes returns the APFS volumes of the attached image.
+func (r AttachResult) APFSVolumes() ([]APFSVolume, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return APFSVolumes(r.DeviceNode().String())
+}
+
+// AddAPFSVolume adds the volume name with role, if not empty, to the APFS container of the attached image whose whole disk is deviceNode.
+// The image must be attached read/write. The returns added volume and error.
+func AddAPFSVolume(deviceNode, name string, role APFSRole) (APFSVolume, error) {
+	return DefaultClient.AddAPFSVolume(deviceNode, name, role)
+}
+
+// AddAPFSVolume is like the package-level AddAPFSVolume, but runs diskutil with the configuration of c.
+// The volume is not added in read-only mode.
+func (c *Client) AddAPFSVolume(deviceNode, name string, role APFSRole) (APFSVolume, error) {
+	containers, err := c.APFSContainers(deviceNode)
+	if err != nil {
+		return APFSVolume{}, err
+	}
+	if len(containers) != 1 {
+		return APFSVolume{}, fmt.Errorf("%s has %d APFS containers, want 1", deviceNode, len(containers))
+	}
+	ct := containers[0]
+
+	args := []string{"apfs", "addVolume", ct.Reference, "APFS", name, "-nomount"}
+	if role != "" {
+		flag, ok := apfsRoleFlags[role]
+		if !ok {
+			return APFSVolume{}, fmt.Errorf("unsupported APFS volume role %q", role)
+		}
+		args = append(args, "-role", flag)
+	}
+	cmd := c.toolCommand("diskutil", args...)
+	cmd.target, cmd.modifies = ct.Reference, true
+	if _, _, err := c.runTool(cmd); err != nil {
+		return APFSVolume{}, err
+	}
+
+	existing := make(map[string]bool)
+	for _, v := range ct.Volumes {
+		existing[v.DeviceIdentifier] = true
+	}
+	volumes, err := c.APFSVolumes(ct.Reference)
+	if err != nil {
+		return APFSVolume{}, err
+	}
+	for _, v := range volumes {
+		if !existing[v.DeviceIdentifier] && v.Name == name {
+			return v, nil
+		}
+	}
+
+	return APFSVolume{}, fmt.Errorf("added volume %s: %w", name, ErrNoAPFSVolume)
+}
+
+// DeleteAPFSVolume deletes the APFS volume v, erasing its data.
+func DeleteAPFSVolume(v APFSVolume) error {
+	return DefaultClient.DeleteAPFSVolume(v)
+}
+
+// DeleteAPFSVolume is like the package-level DeleteAPFSVolume, but runs diskutil with the configuration of c.
+// The volume is not deleted in read-only mode.
+func (c *Client) DeleteAPFSVolume(v APFSVolume) error {
+	cmd := c.toolCommand("diskutil", "apfs", "deleteVolume", v.DeviceIdentifier)
+	cmd.target, cmd.modifies = v.DeviceNode(), true
+	_, _, err := c.runTool(cmd)
+	return err
+}
+
+// MountAPFSVolume mounts the first volume with role of the attached image whose whole disk is deviceNode.
+// It is mounted on mountPoint, or under /Volumes if mountPoint is empty. The returns mounted volume and error.
+//
+// Images of a macOS installation hold several volumes, of which attach mounts only some, so a specific one is mounted by its role.
+func MountAPFSVolume(deviceNode string, role APFSRole, mountPoint string) (APFSVolume, error) {
+	return DefaultClient.MountAPFSVolume(deviceNode, role, mountPoint)
+}
+
+// MountAPFSVolume is like the package-level MountAPFSVolume, but runs diskutil with the configuration of c.
+func (c *Client) MountAPFSVolume(deviceNode string, role APFSRole, mountPoint string) (APFSVolume, error) {
+	volumes, err := c.APFSVolumes(deviceNode)
+	if err != nil {
+		return APFSVolume{}, err
+	}
+	for _, v := range volumes {
+		if !v.HasRole(role) {
+			continue
+		}
+		args := []string{"mount"}
+		if mountPoint != "" {
+			args = append(args, "-mountPoint", mountPoint)
+		}
+		if _, _, err := c.runTool(c.toolCommand("diskutil", append(args, v.DeviceIdentifier)...)); err != nil {
+			return APFSVolume{}, err
+		}
+		return v, nil
+	}
+
+	return APFSVolume{}, fmt.Errorf("%s role %s: %w", deviceNode, role, ErrNoAPFSVolume)
+}
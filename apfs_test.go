@@ -0,0 +1,39 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDeleteAPFSVolumeClient(t *testing.T) {
+	v := APFSVolume{DeviceIdentifier: "disk5s2"}
+	ran := false
+	runner := WithToolRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		ran = true
+		return nil, nil, nil
+	}))
+
+	if err := NewClient(runner, WithReadOnly()).DeleteAPFSVolume(v); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only: got %v, want ErrReadOnly", err)
+	}
+	if err := NewClient(runner, WithPolicy(Policy{DenyVerbs: []string{"diskutil"}})).DeleteAPFSVolume(v); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("policy: got %v, want ErrPolicyViolation", err)
+	}
+	var dry *DryRunError
+	if err := NewClient(runner, WithDryRun()).DeleteAPFSVolume(v); !errors.As(err, &dry) || dry.CommandLine() != "diskutil apfs deleteVolume disk5s2" {
+		t.Errorf("dry run: got %v, want a DryRunError", err)
+	}
+	if ran {
+		t.Error("diskutil ran")
+	}
+
+	if err := NewClient(runner).DeleteAPFSVolume(v); err != nil || !ran {
+		t.Errorf("got %v, ran %v, want diskutil run", err, ran)
+	}
+}
@@ -5,15 +5,19 @@
 package hdiutil
 
 import (
-	"fmt"
-	"os/exec"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
-// attachFlag implements a hdiutil attach command flag interface.
-type attachFlag interface {
-	attachFlag() []string
+// AttachFlag is a hdiutil attach command flag, returning its command-line arguments.
+type AttachFlag interface {
+	AttachFlag() []string
 }
 
 type attachRWType int
@@ -23,55 +27,55 @@ const (
 	readwrite
 )
 
-func (a attachRWType) attachFlag() string {
+func (a attachRWType) AttachFlag() []string {
 	switch a {
 	case readonly:
-		return "-readonly"
+		return []string{"-readonly"}
 	case readwrite:
-		return "-readwrite"
+		return []string{"-readwrite"}
 	default:
-		return ""
+		return nil
 	}
 }
 
 type attachKernel bool
 
-func (a attachKernel) attachFlag() []string { return boolNoFlag("kernel", bool(a)) }
+func (a attachKernel) AttachFlag() []string { return boolNoFlag("kernel", bool(a)) }
 
 type attachNotRemovable bool
 
-func (a attachNotRemovable) attachFlag() []string { return boolFlag("notremovable", bool(a)) }
+func (a attachNotRemovable) AttachFlag() []string { return boolFlag("notremovable", bool(a)) }
 
 type attachMount string
 
-func (a attachMount) attachFlag() []string { return stringFlag("mount", string(a)) }
+func (a attachMount) AttachFlag() []string { return stringFlag("mount", string(a)) }
 
 type attachNoMount bool
 
-func (a attachNoMount) attachFlag() []string { return boolFlag("nomount", bool(a)) }
+func (a attachNoMount) AttachFlag() []string { return boolFlag("nomount", bool(a)) }
 
 // AttachMountRoot mount volumes on subdirectories of path instead of under /Volumes. path must exist.
 //
 // Full mount point paths must be less than MNAMELEN characters (increased from 90 to 1024 in Mac OS X 10.6).
 type AttachMountRoot string
 
-func (a AttachMountRoot) attachFlag() []string { return stringFlag("mountroot", string(a)) }
+func (a AttachMountRoot) AttachFlag() []string { return stringFlag("mountroot", string(a)) }
 
 // AttachMountRandom like AttachMountRoot, but mount point directory names are randomized with mkdtemp(3).
 type AttachMountRandom string
 
-func (a AttachMountRandom) attachFlag() []string { return stringFlag("mountrandom", string(a)) }
+func (a AttachMountRandom) AttachFlag() []string { return stringFlag("mountrandom", string(a)) }
 
 // AttachMountPoint assuming only one volume, mount it at path instead of in /Volumes.
 //
 // See fstab(5) for ways a system administrator can make particular volumes automatically mount in particular filesystem locations by editing the file /etc/fstab.
 type AttachMountPoint string
 
-func (a AttachMountPoint) attachFlag() []string { return stringFlag("mountpoint", string(a)) }
+func (a AttachMountPoint) AttachFlag() []string { return stringFlag("mountpoint", string(a)) }
 
 type attachNoBrowse bool
 
-func (a attachNoBrowse) attachFlag() []string { return boolFlag("nobrowse", bool(a)) }
+func (a attachNoBrowse) AttachFlag() []string { return boolFlag("nobrowse", bool(a)) }
 
 type attachOwners string
 
@@ -80,12 +84,12 @@ const (
 	ownersOff attachOwners = "off"
 )
 
-func (a attachOwners) attachFlag() []string { return stringFlag("owners", string(a)) }
+func (a attachOwners) AttachFlag() []string { return stringFlag("owners", string(a)) }
 
 // AttachDrivekey specify a key/value pair to be set on the device in the IOKit registry.
 type AttachDrivekey [2]string
 
-func (a AttachDrivekey) attachFlag() []string {
+func (a AttachDrivekey) AttachFlag() []string {
 	return stringFlag(a[0]+"="+a[1], "drivekey")
 }
 
@@ -94,7 +98,7 @@ func (a AttachDrivekey) attachFlag() []string {
 // Ranges are inclusive.
 type AttachSection [2]int
 
-func (a AttachSection) attachFlag() []string {
+func (a AttachSection) AttachFlag() []string {
 	var arg string
 	for v := range a {
 		arg = arg + strconv.Itoa(v)
@@ -104,41 +108,41 @@ func (a AttachSection) attachFlag() []string {
 
 type attachVerify bool
 
-func (a attachVerify) attachFlag() []string { return boolNoFlag("verify", bool(a)) }
+func (a attachVerify) AttachFlag() []string { return boolNoFlag("verify", bool(a)) }
 
 type attachIgnoreBadChecksums bool
 
-func (a attachIgnoreBadChecksums) attachFlag() []string {
-	return boolNoFlag("ignoreBadChecksums", bool(a))
+func (a attachIgnoreBadChecksums) AttachFlag() []string {
+	return boolNoFlag("ignorebadchecksums", bool(a))
 }
 
 type attachIdme bool
 
-func (a attachIdme) attachFlag() []string { return boolNoFlag("idme", bool(a)) }
+func (a attachIdme) AttachFlag() []string { return boolNoFlag("idme", bool(a)) }
 
 type atachIdmeReveal bool
 
-func (a atachIdmeReveal) attachFlag() []string { return boolNoFlag("idmereveal", bool(a)) }
+func (a atachIdmeReveal) AttachFlag() []string { return boolNoFlag("idmereveal", bool(a)) }
 
 type attachIdmeTrash bool
 
-func (a attachIdmeTrash) attachFlag() []string { return boolNoFlag("idmetrash", bool(a)) }
+func (a attachIdmeTrash) AttachFlag() []string { return boolNoFlag("idmetrash", bool(a)) }
 
 type attachAutoOpen bool
 
-func (a attachAutoOpen) attachFlag() []string { return boolNoFlag("autoopen", bool(a)) }
+func (a attachAutoOpen) AttachFlag() []string { return boolNoFlag("autoopen", bool(a)) }
 
 type attachAutoOpenRO bool
 
-func (a attachAutoOpenRO) attachFlag() []string { return boolNoFlag("autoopenro", bool(a)) }
+func (a attachAutoOpenRO) AttachFlag() []string { return boolNoFlag("autoopenro", bool(a)) }
 
 type attachAutoOpenRW bool
 
-func (a attachAutoOpenRW) attachFlag() []string { return boolNoFlag("autoopenrw", bool(a)) }
+func (a attachAutoOpenRW) AttachFlag() []string { return boolNoFlag("autoopenrw", bool(a)) }
 
 type attachAutoFsck bool
 
-func (a attachAutoFsck) attachFlag() []string { return boolNoFlag("autofsck", bool(a)) }
+func (a attachAutoFsck) AttachFlag() []string { return boolNoFlag("autofsck", bool(a)) }
 
 const (
 	// AttachReadonly force the resulting device to be read-only.
@@ -238,22 +242,241 @@ const (
 	AttachNoAutoFsck attachAutoFsck = false
 )
 
-var attachRe = regexp.MustCompile(`/dev/disk[\d]+`)
+// AttachRetry retry the attach when it fails with a transient Disk Arbitration error,
+// such as "Resource busy" right after another image was detached.
+//
+// The attach is tried at most Attempts times. The delay before each retry starts at Backoff and doubles,
+// with a random jitter so that concurrent attaches do not retry in lockstep.
+// Only the errors listed in transientAttachErrors are retried.
+// This is independent of any retry of Detach.
+type AttachRetry struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+func (a AttachRetry) AttachFlag() []string { return nil }
 
-// Attach attach the image file. The returns device node path and error.
-func Attach(image string, flags ...attachFlag) (string, error) {
-	cmd := exec.Command(hdiutilPath, "attach", image)
+// delay returns the jittered delay before the retry following the given attempt, counted from 1.
+func (a AttachRetry) delay(attempt int) time.Duration {
+	d := a.Backoff << uint(attempt-1)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
 
-	if len(flags) > 0 {
-		for _, f := range flags {
-			cmd.Args = append(cmd.Args, f.attachFlag()...)
+// transientAttachErrors is the hdiutil attach error messages which are retried with AttachRetry.
+var transientAttachErrors = []string{
+	"resource busy",
+	"resource temporarily unavailable",
+	"device not configured",
+	"operation timed out",
+}
+
+// isTransientAttachError reports whether the attach output out reports a transient failure.
+func isTransientAttachError(out []byte) bool {
+	msg := strings.ToLower(string(out))
+	for _, e := range transientAttachErrors {
+		if strings.Contains(msg, e) {
+			return true
 		}
 	}
+	return false
+}
 
-	out, err := cmd.CombinedOutput()
+var attachRe = regexp.MustCompile(`/dev/disk[\d]+`)
+
+// attachPlist is the hdiutil attach -plist output.
+type attachPlist struct {
+	SystemEntities []SystemEntity `plist:"system-entities"`
+}
+
+// Attach attach the image file. The returns attached device entries and error.
+//
+// It runs hdiutil attach -plist, so the result lists every device entry of the image with its content hint and mount point,
+// see AttachResult.DeviceNode and AttachResult.MountPoints.
+func Attach(image string, flags ...AttachFlag) (AttachResult, error) {
+	return DefaultClient.Attach(image, flags...)
+}
+
+// Attach is like the package-level Attach, but runs hdiutil with the configuration of c.
+func (c *Client) Attach(image string, flags ...AttachFlag) (AttachResult, error) {
+	cmd := c.plistCommand("attach", image)
+	cmd.target = image
+
+	result := AttachResult{ImagePath: image}
+	stdout, stderr, err := c.runAttach(cmd, flags)
 	if err != nil {
-		return "", fmt.Errorf("%v: %s", err, out)
+		result.Err = newAttachError(image, stderr, err)
+		return result, result.Err
 	}
 
-	return string(attachRe.Find(out)), nil
+	parsed, err := ParseAttachPlist(stdout)
+	if err != nil {
+		result.Err = err
+		return result, err
+	}
+	result.SystemEntities, result.Raw = parsed.SystemEntities, parsed.Raw
+	if dev := result.DeviceNode(); dev != "" {
+		recordAttach(dev.String())
+	}
+
+	return result, nil
+}
+
+// ParseAttachPlist decodes the hdiutil attach -plist output data. The ImagePath of the result is not set, as the output does not report it.
+func ParseAttachPlist(data []byte) (AttachResult, error) {
+	var out attachPlist
+	if err := unmarshalPlist(data, &out); err != nil {
+		return AttachResult{}, err
+	}
+	return AttachResult{SystemEntities: out.SystemEntities, Raw: rawPlist(data)}, nil
+}
+
+// runAttach adds flags to the attach invocation cmd and runs it, retrying the transient failures according to the AttachRetry among flags.
+func (c *Client) runAttach(cmd *command, flags []AttachFlag) (stdout, stderr []byte, err error) {
+	var retry AttachRetry
+	for _, f := range flags {
+		if r, ok := f.(AttachRetry); ok {
+			retry = r
+		}
+		cmd.flag(f, f.AttachFlag())
+	}
+
+	for attempt := 1; ; attempt++ {
+		stdout, stderr, err = c.run(cmd)
+		if err == nil || attempt >= retry.Attempts || !isTransientAttachError(stderr) {
+			return stdout, stderr, err
+		}
+		time.Sleep(retry.delay(attempt))
+	}
+}
+
+// AttachResult is the result of attaching an image.
+type AttachResult struct {
+	// ImagePath is the path of the image, as given to Attach or AttachAll.
+	ImagePath string
+
+	// SystemEntities is the device entries of the attached image, the whole disk first.
+	SystemEntities []SystemEntity
+
+	// Err is the error attaching the image, if it was not attached.
+	Err error
+
+	// Raw is the property list output of hdiutil attach, or nil for the results of AttachAll.
+	Raw []byte
+}
+
+// DeviceNode returns the whole disk device node of the attached image, or empty if it was not attached.
+func (r AttachResult) DeviceNode() DeviceNode {
+	if len(r.SystemEntities) == 0 {
+		return ""
+	}
+	return DeviceNode(r.SystemEntities[0].DevEntry).Whole()
+}
+
+// MountPoints returns the mount points of the attached image volumes.
+func (r AttachResult) MountPoints() []string {
+	return entityMountPoints(r.SystemEntities)
+}
+
+// AttachAll attach several image files with a single hdiutil process, which is faster than attaching them one by one.
+//
+// The returns results in the order of images, and the errors of the images which were not attached joined.
+// hdiutil stops at the first image failing to attach, so the following images are reported as not attached either;
+// the images attached before the failure stay attached.
+//
+// The attach output does not tell which image each device belongs to, so the devices are looked up with Info before and after attaching.
+// When the attach fails, an image which was already attached is reported as attached only if an image after it was attached by this call.
+// In dry-run mode, no image is reported as attached.
+//
+// A segmented image is attached by its first segment, which references the others, so only the first segment of each image should be in images.
+func AttachAll(images []string, flags ...AttachFlag) ([]AttachResult, error) {
+	return DefaultClient.AttachAll(images, flags...)
+}
+
+// AttachAll is like the package-level AttachAll, but runs hdiutil with the configuration of c.
+func (c *Client) AttachAll(images []string, flags ...AttachFlag) ([]AttachResult, error) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	before := make(map[DeviceNode]bool)
+	if !c.dryRun {
+		info, err := c.Info()
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range info.Images {
+			before[img.DeviceNode()] = true
+		}
+	}
+
+	cmd := c.command("attach", images...)
+	cmd.target = images[0]
+	cmd.targets = images
+
+	_, stderr, runErr := c.runAttach(cmd, flags)
+
+	var attached []InfoImage
+	if !c.dryRun {
+		info, err := c.Info()
+		if err == nil {
+			attached = info.Images
+		} else if runErr == nil {
+			return nil, err
+		}
+	}
+
+	results := make([]AttachResult, len(images))
+	last := -1 // the last image attached by this call
+	for i, image := range images {
+		results[i].ImagePath = image
+		for _, img := range attached {
+			if sameFile(image, img.ImagePath) {
+				results[i].SystemEntities = img.SystemEntities
+				if !before[img.DeviceNode()] {
+					last = i
+					break
+				}
+			}
+		}
+	}
+
+	var errs []error
+	for i, image := range images {
+		// hdiutil attaches the images in order, so when it fails, the already attached images after the last one it attached were not reached.
+		if runErr != nil && i > last {
+			results[i].SystemEntities = nil
+		}
+		if dev := results[i].DeviceNode(); dev != "" {
+			recordAttach(dev.String())
+			continue
+		}
+
+		if runErr != nil {
+			results[i].Err = newAttachError(image, stderr, runErr)
+		} else {
+			results[i].Err = &AttachError{Image: image, Err: errors.New("image not found in hdiutil info")}
+		}
+		errs = append(errs, results[i].Err)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// sameFile reports whether the paths a and b name the same file.
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fa, fb)
 }
@@ -0,0 +1,86 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+// attachAllRunner returns a Runner attaching the images of attach to the devices of after,
+// and failing after the first of them if fail is set. Info lists before until attach runs.
+func attachAllRunner(t *testing.T, before, after map[string]string, fail bool) Runner {
+	t.Helper()
+	info := func(images map[string]string) []byte {
+		var out SystemImagesInfo
+		for image, dev := range images {
+			out.Images = append(out.Images, InfoImage{ImagePath: image, SystemEntities: []SystemEntity{{DevEntry: dev}}})
+		}
+		b, err := marshalPlist(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	attached := before
+	return RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		switch args[0] {
+		case "info":
+			return info(attached), nil, nil
+		case "attach":
+			attached = after
+			if fail {
+				return nil, []byte("hdiutil: attach failed - no mountable file systems\n"), replayExitError(1)
+			}
+		}
+		return nil, nil, nil
+	})
+}
+
+func TestAttachAll(t *testing.T) {
+	images := []string{"/tmp/a.dmg", "/tmp/b.dmg", "/tmp/c.dmg"}
+	tests := []struct {
+		name          string
+		before, after map[string]string
+		fail          bool
+		want          []bool
+	}{
+		{
+			name:   "success",
+			before: map[string]string{"/tmp/b.dmg": "/dev/disk4"},
+			after:  map[string]string{"/tmp/a.dmg": "/dev/disk5", "/tmp/b.dmg": "/dev/disk4", "/tmp/c.dmg": "/dev/disk6"},
+			want:   []bool{true, true, true},
+		},
+		{
+			name:   "already attached",
+			before: map[string]string{"/tmp/a.dmg": "/dev/disk4", "/tmp/c.dmg": "/dev/disk5"},
+			after:  map[string]string{"/tmp/a.dmg": "/dev/disk4", "/tmp/c.dmg": "/dev/disk5"},
+			fail:   true,
+			want:   []bool{false, false, false},
+		},
+		{
+			name:   "attached before the failure",
+			before: map[string]string{"/tmp/a.dmg": "/dev/disk4", "/tmp/c.dmg": "/dev/disk5"},
+			after:  map[string]string{"/tmp/a.dmg": "/dev/disk4", "/tmp/b.dmg": "/dev/disk6", "/tmp/c.dmg": "/dev/disk5"},
+			fail:   true,
+			want:   []bool{true, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(WithRunner(attachAllRunner(t, tt.before, tt.after, tt.fail)))
+			results, err := c.AttachAll(images)
+			if (err != nil) != tt.fail {
+				t.Errorf("err = %v, want failure %v", err, tt.fail)
+			}
+			for i, r := range results {
+				if got := r.DeviceNode() != ""; got != tt.want[i] || got == (r.Err != nil) {
+					t.Errorf("%s: attached %v, err %v, want attached %v", r.ImagePath, got, r.Err, tt.want[i])
+				}
+			}
+		})
+	}
+}
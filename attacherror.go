@@ -0,0 +1,121 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// AttachCause is the cause of an attach failure, parsed from the hdiutil diagnostics.
+type AttachCause int
+
+const (
+	// AttachCauseUnknown is a failure whose cause is not recognized.
+	AttachCauseUnknown AttachCause = iota
+
+	// AttachCauseNoMountableFS is an image attached without any volume the system can mount, such as an unformatted
+	// or Linux image. Attaching it with AttachNoMount gives access to its devices.
+	AttachCauseNoMountableFS
+
+	// AttachCauseNotRecognized is a file which is not a disk image, or of a format the system does not support.
+	AttachCauseNotRecognized
+
+	// AttachCauseAuthentication is an encrypted image whose passphrase or key is missing or wrong, see Passphrase.
+	AttachCauseAuthentication
+
+	// AttachCauseCorrupt is an image whose checksum does not match or whose data is damaged.
+	// Attaching it with AttachNoVerify may still give access to its undamaged data.
+	AttachCauseCorrupt
+
+	// AttachCauseBusy is a transient failure of Disk Arbitration, which AttachRetry retries.
+	AttachCauseBusy
+
+	// AttachCauseNotFound is an image which does not exist.
+	AttachCauseNotFound
+
+	// AttachCausePermission is an image the current user cannot read, or a flag which requires root privileges.
+	AttachCausePermission
+)
+
+func (c AttachCause) String() string {
+	switch c {
+	case AttachCauseNoMountableFS:
+		return "no mountable file systems"
+	case AttachCauseNotRecognized:
+		return "image not recognized"
+	case AttachCauseAuthentication:
+		return "authentication error"
+	case AttachCauseCorrupt:
+		return "corrupt image"
+	case AttachCauseBusy:
+		return "resource busy"
+	case AttachCauseNotFound:
+		return "image not found"
+	case AttachCausePermission:
+		return "permission denied"
+	}
+	return "unknown"
+}
+
+// attachCauseMessages is the hdiutil attach diagnostics of each cause, lower-cased.
+var attachCauseMessages = []struct {
+	cause AttachCause
+	msgs  []string
+}{
+	{AttachCauseNoMountableFS, []string{"no mountable file systems"}},
+	{AttachCauseNotRecognized, []string{"not recognized"}},
+	{AttachCauseAuthentication, []string{"authentication error", "incorrect passphrase"}},
+	{AttachCauseCorrupt, []string{"corrupt image", "checksum", "image data corrupted"}},
+	{AttachCauseBusy, transientAttachErrors},
+	{AttachCauseNotFound, []string{"no such file or directory"}},
+	{AttachCausePermission, []string{"permission denied", "operation not permitted"}},
+}
+
+// AttachError is the error of a failed attach, with its cause parsed from the hdiutil diagnostics
+// so that callers can choose a remediation, such as retrying with AttachNoMount.
+type AttachError struct {
+	Image string
+	Cause AttachCause
+	Err   error
+}
+
+func (e *AttachError) Error() string {
+	if e.Cause == AttachCauseUnknown {
+		return "attach " + e.Image + ": " + e.Err.Error()
+	}
+	return "attach " + e.Image + ": " + e.Cause.String() + ": " + e.Err.Error()
+}
+
+func (e *AttachError) Unwrap() error { return e.Err }
+
+// newAttachError returns the *AttachError of the failed attach of image, whose standard error is stderr.
+func newAttachError(image string, stderr []byte, err error) *AttachError {
+	return &AttachError{Image: image, Cause: parseAttachCause(stderr, err), Err: err}
+}
+
+// parseAttachCause returns the cause of the attach failure err, whose standard error is stderr.
+func parseAttachCause(stderr []byte, err error) AttachCause {
+	msg := strings.ToLower(string(stderr))
+	for _, c := range attachCauseMessages {
+		for _, m := range c.msgs {
+			if strings.Contains(msg, m) {
+				return c.cause
+			}
+		}
+	}
+
+	var codeErr *CodeError
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return AttachCauseNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return AttachCausePermission
+	case errors.As(err, &codeErr) && codeErr.Code == OSErrAuthentication:
+		return AttachCauseAuthentication
+	}
+	return AttachCauseUnknown
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Operation describes an hdiutil invocation which destroys or irreversibly changes data.
+type Operation struct {
+	// Verb is the hdiutil verb, such as "create".
+	Verb string `json:"verb"`
+
+	// Target is the image or device the operation applies to.
+	Target string `json:"target"`
+
+	// Args is the hdiutil arguments following the verb.
+	Args []string `json:"args"`
+}
+
+// destructiveVerbs is the verbs which are always destructive.
+var destructiveVerbs = map[string]bool{
+	"burn":      true,
+	"chpass":    true,
+	"erasekeys": true,
+}
+
+// operation returns the Operation of cmd and whether it is destructive:
+// create with -ov, erasekeys, chpass, burn, detach or eject with -force, a resize shrinking the image and the destructive helper tools.
+// The invocations made by the package itself, marked with internalCall, are not operations of the caller.
+func (cmd *command) operation() (Operation, bool) {
+	op := Operation{Verb: cmd.verb, Target: cmd.target, Args: cmd.args}
+	if cmd.call.internal {
+		return op, false
+	}
+	if cmd.tool {
+		return op, cmd.destructive
+	}
+
+	switch cmd.verb {
+	case "create":
+		return op, cmd.hasArg("-ov")
+	case "detach", "eject":
+		return op, cmd.hasArg("-force")
+	case "resize":
+		return op, cmd.shrink && !cmd.hasArg("-limits")
+	}
+	return op, destructiveVerbs[cmd.verb]
+}
+
+// hasArg reports whether arg is an argument of cmd.
+func (cmd *command) hasArg(arg string) bool {
+	for _, a := range cmd.args {
+		if a == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// auditRecord is a line of the audit log, in JSON.
+type auditRecord struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	SudoUser string    `json:"sudo_user,omitempty"`
+	Operation
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// auditWriter serializes the audit records written by concurrent invocations.
+type auditWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithAuditWriter records every destructive invocation of the Client to w, one JSON object per line,
+// with the timestamp, user, target and outcome.
+//
+// The destructive invocations are create with CreateOV, erasekeys, chpass, burn, detach with DetachForce, eject with EjectForce
+// and a resize shrinking the image. The invocations the package makes to clean up, such as the forced detach of the images
+// attached by Compare, are not recorded.
+func WithAuditWriter(w io.Writer) ClientOption {
+	return func(c *Client) {
+		c.audit = &auditWriter{w: w}
+	}
+}
+
+// auditInvocation records the finished invocation cmd to the Client audit writer if it is destructive.
+func (c *Client) auditInvocation(cmd *command, start time.Time, err error) {
+	if c.audit == nil {
+		return
+	}
+	op, ok := cmd.operation()
+	if !ok {
+		return
+	}
+	op.Args = redactArgs(op.Args)
+
+	rec := auditRecord{
+		Time:      start,
+		User:      currentUser(),
+		SudoUser:  os.Getenv("SUDO_USER"),
+		Operation: op,
+		Outcome:   "success",
+	}
+	if err != nil {
+		rec.Outcome = "failure"
+		rec.Error = err.Error()
+	}
+
+	b, merr := json.Marshal(rec)
+	if merr != nil {
+		return
+	}
+	c.audit.mu.Lock()
+	c.audit.w.Write(append(b, '\n'))
+	c.audit.mu.Unlock()
+}
+
+// currentUser returns the name of the user running the process, or its uid if it has no name.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(os.Getuid())
+}
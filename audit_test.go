@@ -0,0 +1,77 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// auditedVerbs runs fn with a Client auditing to a buffer, whose runner reports the image to be 409600 sectors,
+// and returns the verbs of the audit records.
+func auditedVerbs(t *testing.T, fn func(c *Client)) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	c := NewClient(WithAuditWriter(&buf), WithRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		if len(args) > 1 && args[1] == "-limits" {
+			return []byte(" min \t cur \t max \n81920\t409600\t34359738368\n"), nil, nil
+		}
+		return nil, nil, nil
+	})))
+	fn(c)
+
+	var verbs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec auditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		verbs = append(verbs, rec.Verb+" "+strings.Join(rec.Args, " "))
+	}
+	return verbs
+}
+
+func TestAuditResize(t *testing.T) {
+	tests := []struct {
+		size    SizeSpec
+		flags   []ResizeFlag
+		audited bool
+	}{
+		{Size(100 << 20), nil, true},
+		{CreateSize("300m"), nil, false},
+		{ResizeSectors(409600), nil, false},
+		{ResizeSectors(409599), nil, true},
+		{ResizeMin, nil, true},
+		{Size(100 << 20), []ResizeFlag{ResizeGrowonly}, false},
+	}
+	for _, tt := range tests {
+		verbs := auditedVerbs(t, func(c *Client) {
+			if err := c.Resize("/tmp/image.sparseimage", tt.size, tt.flags...); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if audited := len(verbs) > 0; audited != tt.audited {
+			t.Errorf("resize %q: audit records %q, want audited %v", tt.size.SizeSpec(), verbs, tt.audited)
+		}
+	}
+}
+
+func TestAuditInternal(t *testing.T) {
+	verbs := auditedVerbs(t, func(c *Client) {
+		c.Detach("/dev/disk4", DetachForce, internalCall())
+		c.Detach("/dev/disk5", DetachForce)
+		c.Detach("/dev/disk6")
+	})
+	if want := []string{"detach /dev/disk5 -force"}; strings.Join(verbs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("audit records %q, want %q", verbs, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "path/filepath"
+
+// BlessFlag is a bless(8) command flag, returning its command-line arguments.
+type BlessFlag interface {
+	BlessFlag() []string
+}
+
+// BlessFolder bless the given directory, which must contain a bootable system, instead of the default System/Library/CoreServices of the volume.
+type BlessFolder string
+
+func (b BlessFolder) BlessFlag() []string { return []string{"--folder", string(b)} }
+
+// BlessFile set the given file as the booter, in addition to blessing its directory.
+type BlessFile string
+
+func (b BlessFile) BlessFlag() []string { return []string{"--file", string(b)} }
+
+// BlessBootinfo create a BootX file from the given file (usually /usr/standalone/ppc/bootx.bootinfo) in the blessed folder.
+type BlessBootinfo string
+
+func (b BlessBootinfo) BlessFlag() []string { return []string{"--bootinfo", string(b)} }
+
+// BlessBootefi create a boot.efi file from the given file (usually /usr/standalone/i386/boot.efi) in the blessed folder.
+type BlessBootefi string
+
+func (b BlessBootefi) BlessFlag() []string { return []string{"--bootefi", string(b)} }
+
+// BlessOpenfolder set the directory which the Finder opens when the volume is mounted.
+type BlessOpenfolder string
+
+func (b BlessOpenfolder) BlessFlag() []string { return []string{"--openfolder", string(b)} }
+
+// BlessLabel set the label shown for the blessed system in the firmware boot picker.
+type BlessLabel string
+
+func (b BlessLabel) BlessFlag() []string { return []string{"--label", string(b)} }
+
+type blessVerbose bool
+
+func (b blessVerbose) BlessFlag() []string {
+	if b {
+		return []string{"--verbose"}
+	}
+	return nil
+}
+
+const (
+	// BlessVerbose print verbose output.
+	BlessVerbose blessVerbose = true
+)
+
+// Bless bless the system folder of the volume mounted at mountPoint so that it becomes bootable.
+//
+// Without BlessFolder, the System/Library/CoreServices directory of the volume is blessed.
+// Used together with MakehybridHFSBlessedDirectory, the blessed volume contents of an attached read/write image can be turned into a bootable hybrid image.
+func Bless(mountPoint string, flags ...BlessFlag) error {
+	return DefaultClient.Bless(mountPoint, flags...)
+}
+
+// Bless is like the package-level Bless, but runs bless with the configuration of c.
+// The volume is not blessed in read-only mode.
+func (c *Client) Bless(mountPoint string, flags ...BlessFlag) error {
+	path := defaultBlessPath
+	if p, err := lookBinary(defaultBlessPath, "bless"); err == nil {
+		path = p
+	}
+
+	var args []string
+	folder := true
+	for _, flag := range flags {
+		if _, ok := flag.(BlessFolder); ok {
+			folder = false
+		}
+		args = append(args, flag.BlessFlag()...)
+	}
+	if folder {
+		args = append(args, BlessFolder(filepath.Join(mountPoint, "System", "Library", "CoreServices")).BlessFlag()...)
+	}
+
+	cmd := c.toolCommand(path, args...)
+	cmd.target, cmd.modifies = mountPoint, true
+	_, _, err := c.runTool(cmd)
+	return err
+}
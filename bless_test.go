@@ -0,0 +1,42 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestBless(t *testing.T) {
+	var got []string
+	runner := WithToolRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		got = args[1:]
+		return nil, nil, nil
+	}))
+
+	tests := []struct {
+		flags []BlessFlag
+		want  []string
+	}{
+		{nil, []string{"--folder", "/Volumes/Boot/System/Library/CoreServices"}},
+		{[]BlessFlag{BlessFolder("/Volumes/Boot/Boot"), BlessLabel("Boot")}, []string{"--folder", "/Volumes/Boot/Boot", "--label", "Boot"}},
+	}
+	for _, tt := range tests {
+		if err := NewClient(runner).Bless("/Volumes/Boot", tt.flags...); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Bless(%v) ran %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+
+	got = nil
+	if err := NewClient(runner, WithReadOnly()).Bless("/Volumes/Boot"); !errors.Is(err, ErrReadOnly) || got != nil {
+		t.Errorf("read-only: got %v, ran %q, want ErrReadOnly", err, got)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BurnFlag is a hdiutil burn command flag, returning its command-line arguments.
+type BurnFlag interface {
+	BurnFlag() []string
+}
+
+// BurnDevice specify a device to use for burning, as listed by hdiutil burn -list.
+type BurnDevice string
+
+func (b BurnDevice) BurnFlag() []string { return stringFlag("device", string(b)) }
+
+// BurnSpeed specify the desired burn speed as an x-factor, such as 8 for 8x. The default is the maximum speed of the drive and media.
+// BurnMaxSpeed requests the maximum speed explicitly.
+type BurnSpeed int
+
+func (b BurnSpeed) BurnFlag() []string {
+	if b <= 0 {
+		return stringFlag("speed", "max")
+	}
+	return stringFlag("speed", strconv.Itoa(int(b)))
+}
+
+// BurnMaxSpeed burns at the maximum speed of the drive and media.
+const BurnMaxSpeed BurnSpeed = 0
+
+// BurnResult is the result of burning an image.
+type BurnResult struct {
+	// Image is the burned image.
+	Image string
+
+	// Device is the burning device given with BurnDevice, or empty if hdiutil used the first available one.
+	Device string
+
+	// Size is the size of the burned image, in bytes.
+	Size int64
+
+	// Verified reports whether hdiutil verified the burned media, which it does unless BurnNoVerifyBurn is given.
+	Verified bool
+
+	// Messages is the progress messages reported by hdiutil, such as "Writing track".
+	Messages []string
+}
+
+// Burn burn image to optical media in an attached burning device. The returns burn result and error.
+//
+// The result is parsed from the -puppetstrings output of hdiutil burn; use BurnAndVerify to compare the media with the image.
+func Burn(image string, flags ...BurnFlag) (BurnResult, error) {
+	return DefaultClient.Burn(image, flags...)
+}
+
+// Burn is like the package-level Burn, but runs hdiutil with the configuration of c.
+func (c *Client) Burn(image string, flags ...BurnFlag) (BurnResult, error) {
+	result := BurnResult{Image: image}
+	cmd := c.command("burn", image)
+	cmd.target = image
+	cmd.args = append(cmd.args, Puppetstrings.BurnFlag()...)
+	for _, flag := range flags {
+		if flag == Puppetstrings {
+			continue
+		}
+		if d, ok := flag.(BurnDevice); ok {
+			result.Device = string(d)
+		}
+		cmd.flag(flag, flag.BurnFlag())
+	}
+
+	stdout, _, err := c.run(cmd)
+	result.Messages, result.Verified = parseBurnMessages(stdout)
+	if err != nil {
+		result.Verified = false
+		return result, err
+	}
+
+	if fi, err := os.Stat(image); err == nil {
+		result.Size, _ = diskSize(image, fi)
+	}
+
+	return result, nil
+}
+
+// parseBurnMessages returns the progress messages of the hdiutil burn -puppetstrings output out,
+// and whether they report the verification of the media.
+func parseBurnMessages(out []byte) (messages []string, verified bool) {
+	for _, line := range strings.Split(string(out), "\n") {
+		p, ok := parseProgress(line)
+		if !ok || p.Message == "" {
+			continue
+		}
+		messages = append(messages, p.Message)
+		if strings.HasPrefix(strings.ToLower(p.Message), "verifying") {
+			verified = true
+		}
+	}
+	return messages, verified
+}
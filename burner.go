@@ -0,0 +1,219 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Burner is an optical media burner, as listed by drutil list.
+type Burner struct {
+	// Drive is the drutil drive number, counted from 1.
+	Drive int
+
+	Vendor       string
+	Product      string
+	Revision     string
+	Bus          string
+	SupportLevel string
+}
+
+// Burners returns the available burners.
+func Burners() ([]Burner, error) {
+	return DefaultClient.Burners()
+}
+
+// Burners is like the package-level Burners, but runs drutil with the configuration of c.
+func (c *Client) Burners() ([]Burner, error) {
+	out, _, err := c.runTool(c.toolCommand("drutil", "list"))
+	if err != nil {
+		return nil, err
+	}
+	return parseDrutilList(out)
+}
+
+// parseDrutilList parses the drutil list output, a table whose columns are aligned on its header:
+//
+//	   Vendor   Product           Rev   Bus       SupportLevel
+//	1  HL-DT-ST DVDRW  GX40N      RQ00  USB       Unsupported
+func parseDrutilList(out []byte) ([]Burner, error) {
+	var (
+		burners []Burner
+		cols    []int
+	)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if cols == nil {
+			if strings.Contains(line, "Vendor") {
+				for _, name := range []string{"Vendor", "Product", "Rev", "Bus", "SupportLevel"} {
+					cols = append(cols, strings.Index(line, name))
+				}
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		drive, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		col := func(i int) string {
+			start, end := cols[i], len(line)
+			if i+1 < len(cols) {
+				end = cols[i+1]
+			}
+			if start < 0 || start >= len(line) {
+				return ""
+			}
+			if end > len(line) || end < start {
+				end = len(line)
+			}
+			return strings.TrimSpace(line[start:end])
+		}
+		burners = append(burners, Burner{
+			Drive:        drive,
+			Vendor:       col(0),
+			Product:      col(1),
+			Revision:     col(2),
+			Bus:          col(3),
+			SupportLevel: col(4),
+		})
+	}
+	if cols == nil {
+		return nil, fmt.Errorf("drutil list: unexpected output: %s", out)
+	}
+	return burners, sc.Err()
+}
+
+// mediaDevice returns the device node of the media in the burner drive, from the drutil status output.
+func (c *Client) mediaDevice(drive int) (string, error) {
+	out, _, err := c.runTool(c.toolCommand("drutil", "-drive", strconv.Itoa(drive), "status"))
+	if err != nil {
+		return "", err
+	}
+	if m := attachRe.Find(out); m != nil {
+		return string(m), nil
+	}
+	return "", fmt.Errorf("drutil status: no media device in drive %d", drive)
+}
+
+// BurnDrive select the drutil drive whose media is verified by BurnAndVerify, 1 by default.
+// It must be the drive burning the image, which is the first one unless BurnDevice is given.
+// It is ignored by Burn.
+type BurnDrive int
+
+func (b BurnDrive) BurnFlag() []string { return nil }
+
+// BurnReport is the report of BurnAndVerify.
+type BurnReport struct {
+	// Burner is the burner which burned the image.
+	Burner Burner
+
+	// Device is the device node of the burned media.
+	Device string
+
+	// ChecksumType is the type of ImageChecksum and MediaChecksum, ChecksumSHA256.
+	ChecksumType  ChecksumType
+	ImageChecksum ChecksumValue
+	MediaChecksum ChecksumValue
+
+	// Verified reports whether the media checksum matches the image checksum.
+	Verified bool
+}
+
+// BurnAndVerify burn image like Burn, then compares the checksum of the burned media against the image.
+//
+// The burned media is padded, so only as many bytes as the image data are read back from it.
+// The media is ejected once verified, and the failure to eject it is returned.
+// A report is returned along with the error if the image was burned but could not be verified.
+func BurnAndVerify(image string, flags ...BurnFlag) (*BurnReport, error) {
+	return DefaultClient.BurnAndVerify(image, flags...)
+}
+
+// BurnAndVerify is like the package-level BurnAndVerify, but runs hdiutil and drutil with the configuration of c.
+func (c *Client) BurnAndVerify(image string, flags ...BurnFlag) (report *BurnReport, err error) {
+	report = &BurnReport{ChecksumType: ChecksumSHA256}
+
+	drive := 1
+	for _, flag := range flags {
+		if d, ok := flag.(BurnDrive); ok {
+			drive = int(d)
+		}
+	}
+	burners, err := c.Burners()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range burners {
+		if b.Drive == drive {
+			report.Burner = b
+		}
+	}
+	if report.Burner.Drive == 0 {
+		return nil, fmt.Errorf("no burner drive %d", drive)
+	}
+
+	info, err := c.ImageInfo(image)
+	if err != nil {
+		return nil, err
+	}
+	size := info.SizeInformation.SectorCount * sectorSize
+	if report.ImageChecksum, err = c.Checksum(image, report.ChecksumType); err != nil {
+		return nil, err
+	}
+
+	// keep the media in the drive to read it back.
+	if _, err := c.Burn(image, append(flags, BurnNoEject)...); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, _, eerr := c.runTool(c.toolCommand("drutil", "-drive", strconv.Itoa(drive), "eject")); eerr != nil && err == nil {
+			err = eerr
+		}
+	}()
+
+	if report.Device, err = c.mediaDevice(drive); err != nil {
+		return report, err
+	}
+	if report.MediaChecksum, err = mediaChecksum(report.Device, size); err != nil {
+		return report, err
+	}
+	report.Verified = report.ImageChecksum.Equal(report.MediaChecksum)
+
+	return report, nil
+}
+
+// mediaChecksum returns the SHA-256 checksum of the first size bytes of the burned media device dev,
+// which hold the image data followed by the padding of the burner.
+func mediaChecksum(dev string, size int64) (ChecksumValue, error) {
+	f, err := os.Open(dev)
+	if err != nil {
+		return ChecksumValue{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, io.LimitReader(f, size))
+	if err != nil {
+		return ChecksumValue{}, fmt.Errorf("read %s: %w", dev, err)
+	}
+	if n < size {
+		return ChecksumValue{}, fmt.Errorf("read %s: media has %d bytes, less than the %d bytes of the image data", dev, n, size)
+	}
+	sum := h.Sum(nil)
+	return ChecksumValue{Type: ChecksumSHA256, Hex: hex.EncodeToString(sum), Bytes: sum}, nil
+}
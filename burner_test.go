@@ -0,0 +1,64 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseDrutilList(t *testing.T) {
+	out := `   Vendor   Product           Rev   Bus       SupportLevel
+1  HL-DT-ST DVDRW  GX40N      RQ00  USB       Unsupported
+2  MATSHITA DVD-R   UJ-898    HE13  ATAPI     Apple Shipping
+`
+	got, err := parseDrutilList([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Burner{
+		{Drive: 1, Vendor: "HL-DT-ST", Product: "DVDRW  GX40N", Revision: "RQ00", Bus: "USB", SupportLevel: "Unsupported"},
+		{Drive: 2, Vendor: "MATSHITA", Product: "DVD-R   UJ-898", Revision: "HE13", Bus: "ATAPI", SupportLevel: "Apple Shipping"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseDrutilListEmpty(t *testing.T) {
+	got, err := parseDrutilList([]byte("   Vendor   Product           Rev   Bus       SupportLevel\n"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("got %v, %v, want no burners", got, err)
+	}
+	if _, err := parseDrutilList([]byte("drutil: command not supported\n")); err == nil {
+		t.Error("unexpected output accepted")
+	}
+}
+
+func TestBurnersToolRunner(t *testing.T) {
+	var got []string
+	c := NewClient(WithToolRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		got = args
+		return []byte("   Vendor   Product           Rev   Bus       SupportLevel\n1  MATSHITA DVD-R   UJ-898    HE13  ATAPI     Apple Shipping\n"), nil, nil
+	})))
+	burners, err := c.Burners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"drutil", "list"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+	if len(burners) != 1 || burners[0].Drive != 1 {
+		t.Errorf("got %+v, want drive 1", burners)
+	}
+
+	var dry *DryRunError
+	if _, err := NewClient(WithDryRun()).Burners(); !errors.As(err, &dry) || dry.CommandLine() != "drutil list" {
+		t.Errorf("dry run: got %v, want a DryRunError for drutil list", err)
+	}
+}
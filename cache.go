@@ -0,0 +1,159 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache is a content-addressable store of converted images, keyed by the checksum of the source image data.
+//
+// It avoids repeating expensive conversions, such as to UDZO, of identical inputs.
+// A Cache is safe for concurrent use, and several processes may share its directory:
+// concurrent conversions of the same source are only wasted work, as the artifacts are renamed into place atomically.
+type Cache struct {
+	dir    string
+	client *Client
+
+	// ChecksumType is the checksum identifying the source images. The default is ChecksumSHA256.
+	// ChecksumUDIFCRC32 is faster to compute, but more prone to collisions.
+	ChecksumType ChecksumType
+}
+
+// NewCache returns a new Cache storing the converted images in dir, created if needed.
+func NewCache(dir string) *Cache {
+	return DefaultClient.NewCache(dir)
+}
+
+// NewCache is like the package-level NewCache, but runs hdiutil with the configuration of c.
+func (c *Client) NewCache(dir string) *Cache {
+	return &Cache{dir: dir, client: c}
+}
+
+// GetOrConvert returns the path of the image src converted to format with flags, converting it only if the cache has no artifact
+// for the checksum of src, format and flags.
+//
+// The artifacts are told apart by the arguments of the flags, in any order, but not by the passphrases, which are not arguments:
+// the artifacts of an image encrypted with different passphrases are the same.
+// The returned artifact is shared: it must not be modified, and is valid until removed with Remove.
+func (c *Cache) GetOrConvert(src string, format FormatFlag, flags ...ConvertFlag) (string, error) {
+	key, err := c.key(src, format, flags)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(c.dir, key+formatExt(format))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", err
+	}
+	// hdiutil keeps the extension of the output, so the temporary artifact is renamed as is.
+	tmp := filepath.Join(c.dir, "."+key+"-"+strconv.FormatInt(time.Now().UnixNano(), 36)+formatExt(format))
+	if _, err := c.client.Convert(src, format, tmp, flags...); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Remove removes the artifacts of the image src from the cache.
+func (c *Cache) Remove(src string) error {
+	sum, err := c.checksum(src)
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(c.dir, sum+"-*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// key returns the cache key of src converted to format with flags.
+func (c *Cache) key(src string, format FormatFlag, flags []ConvertFlag) (string, error) {
+	sum, err := c.checksum(src)
+	if err != nil {
+		return "", err
+	}
+	key := sum + "-" + formatName(format)
+	if fk := flagsKey(flags); fk != "" {
+		key += "-" + fk
+	}
+	return key, nil
+}
+
+// flagsKey returns a digest of the canonical arguments of the convert flags, or "" if they have none,
+// so that the artifacts converted without flags keep the key of the format alone.
+// The flags are sorted, and the secret image keys redacted as in the logs.
+func flagsKey(flags []ConvertFlag) string {
+	var canon []string
+	for _, flag := range flags {
+		if args := flag.ConvertFlag(); len(args) > 0 {
+			canon = append(canon, strings.Join(redactArgs(args), "\x00"))
+		}
+	}
+	if len(canon) == 0 {
+		return ""
+	}
+	sort.Strings(canon)
+	sum := sha256.Sum256([]byte(strings.Join(canon, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// checksum returns the file name safe checksum of src.
+func (c *Cache) checksum(src string) (string, error) {
+	typ := c.ChecksumType
+	if typ == "" {
+		typ = ChecksumSHA256
+	}
+	sum, err := c.client.Checksum(src, typ)
+	if err != nil {
+		return "", fmt.Errorf("checksum %s: %w", src, err)
+	}
+	return strings.ToLower(strings.NewReplacer("-", "", "/", "").Replace(string(typ))) + "-" + sum.Hex, nil
+}
+
+// formatName returns the format name of format, such as UDZO.
+func formatName(format FormatFlag) string {
+	args := format.FormatFlag()
+	if len(args) == 0 {
+		return ""
+	}
+	return args[len(args)-1]
+}
+
+// formatExt returns the file name extension hdiutil gives to the images of format.
+func formatExt(format FormatFlag) string {
+	switch formatName(format) {
+	case "UDTO":
+		return ".cdr"
+	case "UDSP":
+		return ".sparseimage"
+	case "UDSB":
+		return ".sparsebundle"
+	}
+	return ".dmg"
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "testing"
+
+func TestFlagsKey(t *testing.T) {
+	if k := flagsKey(nil); k != "" {
+		t.Errorf("no flags: key %q, want empty", k)
+	}
+	if k := flagsKey([]ConvertFlag{WithTimeout(0)}); k != "" {
+		t.Errorf("call options only: key %q, want empty", k)
+	}
+
+	same := [][2][]ConvertFlag{
+		{{AES256, Passphrase("a")}, {Passphrase("b"), AES256}},
+		{{Tgtimagekey{"zlib-level": "9"}, AES128}, {AES128, Tgtimagekey{"zlib-level": "9"}}},
+		{{Tgtimagekey{"passphrase": "a"}}, {Tgtimagekey{"passphrase": "b"}}},
+	}
+	for _, tt := range same {
+		if a, b := flagsKey(tt[0]), flagsKey(tt[1]); a != b {
+			t.Errorf("keys of %v and %v differ: %q, %q", tt[0], tt[1], a, b)
+		}
+	}
+
+	differ := [][2][]ConvertFlag{
+		{nil, {AES256, Passphrase("a")}},
+		{{AES128}, {AES256}},
+		{{Tgtimagekey{"zlib-level": "1"}}, {Tgtimagekey{"zlib-level": "9"}}},
+	}
+	for _, tt := range differ {
+		if a, b := flagsKey(tt[0]), flagsKey(tt[1]); a == b {
+			t.Errorf("keys of %v and %v are both %q", tt[0], tt[1], a)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoStoredChecksum is returned by StoredChecksum when the image does not record a checksum, such as a sparse image.
+var ErrNoStoredChecksum = errors.New("image has no stored checksum")
+
+// ChecksumFlag is a hdiutil checksum command flag, returning its command-line arguments.
+type ChecksumFlag interface {
+	ChecksumFlag() []string
+}
+
+// ChecksumValue is a checksum of the data of an image, tagged with its type.
+type ChecksumValue struct {
+	Type ChecksumType
+
+	// Hex is the lower-case hexadecimal encoding of Bytes.
+	Hex   string
+	Bytes []byte
+}
+
+// ParseChecksumValue returns the checksum of type typ encoded in s, in hexadecimal as printed by hdiutil, such as "$5B1A3B4C".
+// The "$" or "0x" prefix, the spaces and the case of s are ignored.
+func ParseChecksumValue(typ ChecksumType, s string) (ChecksumValue, error) {
+	h := strings.Join(strings.Fields(s), "")
+	h = strings.TrimPrefix(h, "$")
+	if len(h) > 2 && (h[:2] == "0x" || h[:2] == "0X") {
+		h = h[2:]
+	}
+	b, err := hex.DecodeString(h)
+	if err != nil || len(b) == 0 {
+		return ChecksumValue{}, fmt.Errorf("invalid %s checksum %q", typ, s)
+	}
+	return ChecksumValue{Type: typ, Hex: hex.EncodeToString(b), Bytes: b}, nil
+}
+
+// Equal reports whether v and w are the same checksum of the same type. The types are compared regardless of their case.
+func (v ChecksumValue) Equal(w ChecksumValue) bool {
+	return strings.EqualFold(string(v.Type), string(w.Type)) && bytes.Equal(v.Bytes, w.Bytes)
+}
+
+// IsZero reports whether v is the zero ChecksumValue, such as when no checksum was reported.
+func (v ChecksumValue) IsZero() bool { return v.Type == "" && len(v.Bytes) == 0 }
+
+// String returns v as type:hex, such as SHA256:0a7f....
+func (v ChecksumValue) String() string {
+	if v.IsZero() {
+		return ""
+	}
+	return string(v.Type) + ":" + v.Hex
+}
+
+// Checksum calculate the specified checksum on the image data, regardless of image type. The returns computed checksum and error.
+//
+// typ must be one of the ChecksumType constants.
+func Checksum(image string, typ ChecksumType, flags ...ChecksumFlag) (ChecksumValue, error) {
+	return DefaultClient.Checksum(image, typ, flags...)
+}
+
+// Checksum is like the package-level Checksum, but runs hdiutil with the configuration of c.
+func (c *Client) Checksum(image string, typ ChecksumType, flags ...ChecksumFlag) (ChecksumValue, error) {
+	if !checksumTypes[typ] {
+		return ChecksumValue{}, fmt.Errorf("unknown checksum type %q", typ)
+	}
+
+	cmd := c.command("checksum", image, "-type", string(typ))
+	cmd.target = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.ChecksumFlag())
+	}
+
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return ChecksumValue{}, err
+	}
+
+	sum, ok := parseChecksum(out)
+	if !ok {
+		return ChecksumValue{}, fmt.Errorf("no checksum in hdiutil checksum output: %s", out)
+	}
+
+	return ParseChecksumValue(typ, sum)
+}
+
+// StoredChecksum returns the checksum recorded in the UDIF header of image, as reported by hdiutil imageinfo.
+//
+// Unlike Checksum and Verify, it does not read the image data, so it is a quick identity check of an image, not a proof of its integrity.
+// The CRC32 and MD5 checksums of UDIF images are reported as ChecksumUDIFCRC32 and ChecksumUDIFMD5, whose value Checksum computes.
+func StoredChecksum(image string) (ChecksumValue, error) {
+	return DefaultClient.StoredChecksum(image)
+}
+
+// StoredChecksum is like the package-level StoredChecksum, but runs hdiutil with the configuration of c.
+func (c *Client) StoredChecksum(image string) (ChecksumValue, error) {
+	info, err := c.ImageInfo(image)
+	if err != nil {
+		return ChecksumValue{}, err
+	}
+
+	value := strings.TrimSpace(info.ChecksumValue)
+	if info.ChecksumType == "" || info.ChecksumType == "none" || value == "" {
+		return ChecksumValue{}, ErrNoStoredChecksum
+	}
+
+	return ParseChecksumValue(udifChecksumType(info.ChecksumType), value)
+}
+
+// udifChecksumType returns the type of the checksum named name in the UDIF header of an image.
+// The CRC32 and MD5 checksums of the header are the UDIF ones.
+func udifChecksumType(name string) ChecksumType {
+	switch typ := ChecksumType(name); typ {
+	case ChecksumCRC32:
+		return ChecksumUDIFCRC32
+	case ChecksumMD5:
+		return ChecksumUDIFMD5
+	default:
+		return typ
+	}
+}
+
+// parseChecksum returns the checksum value reported by hdiutil checksum in out, such as
+//
+//	calculated CRC32 $5B1A3B4C
+//	SHA-256 checksum = 0a7f...
+func parseChecksum(out []byte) (string, bool) {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(string(lines[i]))
+		if !strings.Contains(strings.ToLower(line), "checksum") && !strings.HasPrefix(line, "calculated") {
+			continue
+		}
+		if j := strings.LastIndexAny(line, "=$"); j >= 0 {
+			line = line[j+1:]
+		} else if j := strings.LastIndexByte(line, ' '); j >= 0 {
+			line = line[j+1:]
+		}
+		if sum := strings.TrimSpace(line); sum != "" {
+			return sum, true
+		}
+	}
+	return "", false
+}
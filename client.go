@@ -0,0 +1,255 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Client runs hdiutil verbs with a shared configuration: the hdiutil binary, its environment, the timeouts,
+// the logger, the policy and the Runner.
+//
+// Every verb is available as a Client method. The package-level functions use DefaultClient.
+// Clients do not share any configuration, so that a service can run the verbs of each tenant with its own,
+// such as derived from a base Client with With.
+// A Client is safe for concurrent use once configured.
+type Client struct {
+	ctx            context.Context
+	timeouts       map[VerbClass]time.Duration
+	defaultTimeout time.Duration
+
+	logger     *slog.Logger
+	logLevel   slog.Level
+	errorLevel slog.Level
+
+	audit   *auditWriter
+	confirm ConfirmFunc
+	policy  *Policy
+
+	readOnly  bool
+	protected []string
+
+	tmpDir   string
+	environ  []string
+	cleanEnv bool
+
+	hdiutilPath    string
+	runner         Runner
+	toolRunner     Runner
+	dryRun         bool
+	sudo           bool
+	priority       Priority
+	defaultFlags   []interface{}
+	noPreflight    bool
+	stdout, stderr io.Writer
+}
+
+// DefaultClient is the Client used by the package-level functions.
+var DefaultClient = NewClient()
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// NewClient returns a new Client configured by opts.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		timeouts:   make(map[VerbClass]time.Duration),
+		logLevel:   slog.LevelInfo,
+		errorLevel: slog.LevelError,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// With returns a new Client with the configuration of c, changed by opts. c is not modified.
+func (c *Client) With(opts ...ClientOption) *Client {
+	d := *c
+	d.timeouts = make(map[VerbClass]time.Duration, len(c.timeouts))
+	for class, t := range c.timeouts {
+		d.timeouts[class] = t
+	}
+	d.protected = append([]string(nil), c.protected...)
+	d.environ = append([]string(nil), c.environ...)
+	d.defaultFlags = append([]interface{}(nil), c.defaultFlags...)
+	for _, opt := range opts {
+		opt(&d)
+	}
+	return &d
+}
+
+// VerbClass classifies the hdiutil verbs by their expected duration, to apply default timeouts.
+type VerbClass int
+
+const (
+	// FastVerbs is the verbs which only query or detach, such as info and detach.
+	FastVerbs VerbClass = iota
+
+	// SlowVerbs is the verbs which read or write whole images, such as create, convert, verify and attach (which may verify the image).
+	SlowVerbs
+)
+
+func (v VerbClass) String() string {
+	switch v {
+	case FastVerbs:
+		return "fast"
+	case SlowVerbs:
+		return "slow"
+	}
+	return "VerbClass(" + strconv.Itoa(int(v)) + ")"
+}
+
+// slowVerbs is the verbs of the SlowVerbs class. The other verbs are FastVerbs.
+var slowVerbs = map[string]bool{
+	"attach":     true,
+	"burn":       true,
+	"checksum":   true,
+	"compact":    true,
+	"convert":    true,
+	"create":     true,
+	"makehybrid": true,
+	"resize":     true,
+	"segment":    true,
+	"verify":     true,
+}
+
+func verbClassOf(verb string) VerbClass {
+	if slowVerbs[verb] {
+		return SlowVerbs
+	}
+	return FastVerbs
+}
+
+// WithVerbTimeout sets the default timeout of the verbs of class.
+//
+// A hung hdiutil (or diskimages-helper) process is killed after the timeout even if the caller does not set any deadline.
+// A zero timeout, the default, means no timeout. WithTimeout overrides it per call.
+func WithVerbTimeout(class VerbClass, d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeouts[class] = d
+	}
+}
+
+// WithDefaultTimeout sets the timeout of the verbs whose class has no timeout set by WithVerbTimeout.
+// A zero timeout, the default, means no timeout. WithTimeout overrides it per call.
+func WithDefaultTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.defaultTimeout = d
+	}
+}
+
+// WithBaseContext sets the context of every hdiutil invocation of the Client, such as the context of a service
+// canceled on shutdown. The context given to a call with WithContext is combined with it: the call is canceled when either is done.
+func WithBaseContext(ctx context.Context) ClientOption {
+	return func(c *Client) {
+		c.ctx = ctx
+	}
+}
+
+// timeout returns the timeout of the invocation of verb configured by call.
+func (c *Client) timeout(verb string, call *callConfig) time.Duration {
+	if call.timeout != nil {
+		return *call.timeout
+	}
+	if d, ok := c.timeouts[verbClassOf(verb)]; ok {
+		return d
+	}
+	return c.defaultTimeout
+}
+
+// context returns the context of an invocation configured by call, combining the base context of c with the one of call.
+// The returned cancel function must be called once the invocation is done.
+func (c *Client) context(call *callConfig) (context.Context, context.CancelFunc) {
+	switch {
+	case c.ctx == nil && call.ctx == nil:
+		return context.Background(), func() {}
+	case c.ctx == nil:
+		return call.ctx, func() {}
+	case call.ctx == nil:
+		return c.ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(call.ctx)
+	stop := context.AfterFunc(c.ctx, func() { cancel(context.Cause(c.ctx)) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// WithTempDir sets the directory where the Client places shadow files, staging folders and intermediate images,
+// and where hdiutil stages its own temporary files through TMPDIR.
+// The default is os.TempDir, which is often too small for multi-gigabyte conversions.
+func WithTempDir(path string) ClientOption {
+	return func(c *Client) {
+		c.tmpDir = path
+	}
+}
+
+// WithEnv adds the environment variables env, in the "KEY=value" form, to the environment of the hdiutil processes,
+// such as LC_ALL=C to get English messages, CFFIXED_USER_HOME, or the proxy variables of the images attached over HTTP.
+// A variable already in the environment is overridden.
+func WithEnv(env ...string) ClientOption {
+	return func(c *Client) {
+		c.environ = append(c.environ, env...)
+	}
+}
+
+// WithCleanEnv makes the hdiutil processes not inherit the environment of the current process,
+// so that they only get the variables set by WithEnv and WithTempDir.
+func WithCleanEnv() ClientOption {
+	return func(c *Client) {
+		c.cleanEnv = true
+	}
+}
+
+// WithOutputWriters copies the standard output and standard error of every hdiutil invocation of the Client
+// to stdout and stderr as it runs, such as os.Stderr for both to show the Verbose and Debug diagnostics.
+// A nil writer discards the stream. WithOutput overrides them per call.
+func WithOutputWriters(stdout, stderr io.Writer) ClientOption {
+	return func(c *Client) {
+		c.stdout, c.stderr = stdout, stderr
+	}
+}
+
+// outputWriters returns the writers the output of an invocation configured by call is copied to.
+func (c *Client) outputWriters(call *callConfig) (stdout, stderr io.Writer) {
+	if call.output {
+		return call.stdout, call.stderr
+	}
+	return c.stdout, c.stderr
+}
+
+// env returns the environment of the hdiutil processes, or nil to inherit the environment of the current process.
+func (c *Client) env() []string {
+	if c.tmpDir == "" && len(c.environ) == 0 && !c.cleanEnv {
+		return nil
+	}
+
+	// not nil even if empty, as a nil environment is inherited, and exec.Cmd keeps the last value of a duplicated variable.
+	env := []string{}
+	if !c.cleanEnv {
+		env = os.Environ()
+	}
+	env = append(env, c.environ...)
+	if c.tmpDir != "" {
+		env = append(env, "TMPDIR="+c.tmpDir)
+	}
+	return env
+}
+
+// tempDir returns the temporary directory of c.
+func (c *Client) tempDir() string {
+	if c.tmpDir != "" {
+		return c.tmpDir
+	}
+	return os.TempDir()
+}
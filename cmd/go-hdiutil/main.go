@@ -2,35 +2,220 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// Command go-hdiutil runs hdiutil verbs through the hdiutil package.
+//
+// Usage:
+//
+//	go-hdiutil [-error-format text|json] [attach image | detach device | verify image]
+//
+// Without a verb, it creates, attaches and detaches a test image.
+//
+// The exit status tells the cause of a failure, so that scripts can branch on it:
+//
+//	0  success
+//	1  other failure
+//	2  usage error
+//	3  not found: the image, device or file does not exist
+//	4  busy: the device or image is in use
+//	5  needs root: the operation requires root privileges
+//	6  passphrase required: the image is encrypted and could not be unlocked
+//	7  verification failed: the image checksum or digest does not match
+//	8  unsupported: the image format or operation is not supported
+//
+// With -error-format json, the error is written to standard error as a JSON object with the keys
+// "error" (the message), "cause" (the name of the exit status, such as "busy"), "exit_status", and,
+// if hdiutil reported an error number, "code" and "code_name".
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"syscall"
 
 	"github.com/go-darwin/hdiutil"
 )
 
+// Exit statuses.
+const (
+	exitOK                 = 0
+	exitFailure            = 1
+	exitUsage              = 2
+	exitNotFound           = 3
+	exitBusy               = 4
+	exitNeedsRoot          = 5
+	exitPassphraseRequired = 6
+	exitVerificationFailed = 7
+	exitUnsupported        = 8
+)
+
+var causeNames = map[int]string{
+	exitFailure:            "failure",
+	exitUsage:              "usage",
+	exitNotFound:           "not-found",
+	exitBusy:               "busy",
+	exitNeedsRoot:          "needs-root",
+	exitPassphraseRequired: "passphrase-required",
+	exitVerificationFailed: "verification-failed",
+	exitUnsupported:        "unsupported",
+}
+
+// errVerification marks the failures of the verify verb.
+var errVerification = errors.New("verification failed")
+
+// errUsage marks the command-line errors.
+var errUsage = errors.New("usage: go-hdiutil [-error-format text|json] [attach image | detach device | verify image]")
+
+// cliError is the error written with -error-format json.
+type cliError struct {
+	Error      string `json:"error"`
+	Cause      string `json:"cause"`
+	ExitStatus int    `json:"exit_status"`
+	Code       int32  `json:"code,omitempty"`
+	CodeName   string `json:"code_name,omitempty"`
+}
+
 func main() {
-	image := "test.sparsebundle"
+	errorFormat := flag.String("error-format", "text", "error output `format`, text or json")
+	flag.Parse()
+	log.SetFlags(0)
+
+	if *errorFormat != "text" && *errorFormat != "json" {
+		log.Printf("unknown error format %q\n%v", *errorFormat, errUsage)
+		os.Exit(exitUsage)
+	}
+
+	err := run(flag.Args())
+	if err == nil {
+		os.Exit(exitOK)
+	}
+
+	status := exitStatus(err)
+	if *errorFormat == "json" {
+		e := cliError{Error: err.Error(), Cause: causeNames[status], ExitStatus: status}
+		var ce *hdiutil.CodeError
+		if errors.As(err, &ce) {
+			e.Code = int32(ce.Code)
+			e.CodeName = ce.Code.Name()
+		}
+		json.NewEncoder(os.Stderr).Encode(e)
+	} else {
+		log.Print(err)
+	}
+	os.Exit(status)
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return roundTrip()
+	}
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	switch verb, target := args[0], args[1]; verb {
+	case "attach":
+		result, err := hdiutil.Attach(target)
+		if err != nil {
+			return err
+		}
+		fmt.Println(result.DeviceNode())
+		for _, mountPoint := range result.MountPoints() {
+			fmt.Println(mountPoint)
+		}
+	case "detach":
+		return hdiutil.Detach(target)
+	case "verify":
+		result, err := hdiutil.Verify(target)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errVerification, err)
+		}
+		if !result.Computed.IsZero() {
+			fmt.Println(result.Computed)
+		}
+	default:
+		return errUsage
+	}
 
-	if err := hdiutil.Create("test", hdiutil.CreateMegabytes(20), hdiutil.CreateHFSPlus, hdiutil.CreateSPARSEBUNDLE); err != nil {
-		log.Fatal(err)
+	return nil
+}
+
+// roundTrip creates, attaches and detaches a test image.
+func roundTrip() error {
+	image, err := hdiutil.Create("test", hdiutil.CreateMegabytes(20), hdiutil.CreateHFSPlus, hdiutil.CreateSPARSEBUNDLE)
+	if err != nil {
+		return err
 	}
 	if _, err := os.Stat(image); err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer os.RemoveAll(image)
 
-	deviceNode, err := hdiutil.Attach(image, hdiutil.AttachMountPoint("./test"), hdiutil.AttachNoVerify, hdiutil.AttachNoAutoFsck)
+	result, err := hdiutil.Attach(image, hdiutil.AttachMountPoint("./test"), hdiutil.AttachNoVerify, hdiutil.AttachNoAutoFsck)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	deviceNode := result.DeviceNode()
+
+	log.Println(deviceNode.Raw())
+	if n, err := deviceNode.Number(); err == nil {
+		log.Println(n)
+	}
+
+	return hdiutil.Detach(deviceNode.String())
+}
+
+// exitStatus returns the exit status reporting the cause of err.
+func exitStatus(err error) int {
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, hdiutil.ErrNeedsRoot):
+		return exitNeedsRoot
+	case errors.Is(err, hdiutil.ErrNotAttached), errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, hdiutil.ErrDigestMismatch):
+		return exitVerificationFailed
+	case errors.Is(err, hdiutil.ErrResourceBusy):
+		return exitBusy
+	}
+
+	var ae *hdiutil.AttachError
+	if errors.As(err, &ae) {
+		switch ae.Cause {
+		case hdiutil.AttachCauseNotFound:
+			return exitNotFound
+		case hdiutil.AttachCauseBusy:
+			return exitBusy
+		case hdiutil.AttachCauseAuthentication:
+			return exitPassphraseRequired
+		case hdiutil.AttachCauseNotRecognized:
+			return exitUnsupported
+		}
 	}
 
-	log.Println(hdiutil.RawDeviceNode(deviceNode))
-	log.Println(hdiutil.DeviceNumber(deviceNode))
+	var ce *hdiutil.CodeError
+	if errors.As(err, &ce) {
+		switch ce.Code {
+		case hdiutil.IOReturnNotFound, hdiutil.IOReturnNoDevice, hdiutil.OSErrFileNotFound, hdiutil.OSErrDirNotFound, hdiutil.Code(syscall.ENOENT):
+			return exitNotFound
+		case hdiutil.IOReturnBusy, hdiutil.IOReturnStillOpen, hdiutil.IOReturnExclusiveAccess, hdiutil.OSErrFileBusy, hdiutil.Code(syscall.EBUSY):
+			return exitBusy
+		case hdiutil.IOReturnNotPrivileged, hdiutil.Code(syscall.EPERM):
+			return exitNeedsRoot
+		case hdiutil.OSErrAuthentication:
+			return exitPassphraseRequired
+		case hdiutil.IOReturnUnsupported, hdiutil.Code(syscall.ENOTSUP):
+			return exitUnsupported
+		}
+	}
 
-	if err := hdiutil.Detach(deviceNode); err != nil {
-		log.Fatal(err)
+	if errors.Is(err, errVerification) {
+		return exitVerificationFailed
 	}
+	return exitFailure
 }
@@ -0,0 +1,327 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"time"
+)
+
+// callConfig is the configuration of a single hdiutil invocation, set by the CallOption values among the verb flags.
+type callConfig struct {
+	ctx     context.Context
+	timeout *time.Duration
+
+	// output reports whether stdout and stderr are set by WithOutput, overriding the ones of the Client.
+	output         bool
+	stdout, stderr io.Writer
+
+	capture *Output
+
+	// internal reports whether the invocation is made by the package itself, such as to clean up, see internalCall.
+	internal bool
+}
+
+// CallOption configures a single hdiutil invocation instead of adding hdiutil arguments.
+// It is accepted by every verb in place of a flag.
+type CallOption struct {
+	apply func(*callConfig)
+}
+
+// AttachFlag implements AttachFlag with no arguments.
+func (o CallOption) AttachFlag() []string { return nil }
+
+// BurnFlag implements BurnFlag with no arguments.
+func (o CallOption) BurnFlag() []string { return nil }
+
+// ChecksumFlag implements ChecksumFlag with no arguments.
+func (o CallOption) ChecksumFlag() []string { return nil }
+
+// ConvertFlag implements ConvertFlag with no arguments.
+func (o CallOption) ConvertFlag() []string { return nil }
+
+// CreateFlag implements CreateFlag with no arguments.
+func (o CallOption) CreateFlag() []string { return nil }
+
+// DetachFlag implements DetachFlag with no arguments.
+func (o CallOption) DetachFlag() []string { return nil }
+
+// EjectFlag implements EjectFlag with no arguments.
+func (o CallOption) EjectFlag() []string { return nil }
+
+// FsidFlag implements FsidFlag with no arguments.
+func (o CallOption) FsidFlag() []string { return nil }
+
+// ImageinfoFlag implements ImageinfoFlag with no arguments.
+func (o CallOption) ImageinfoFlag() []string { return nil }
+
+// InfoFlag implements InfoFlag with no arguments.
+func (o CallOption) InfoFlag() []string { return nil }
+
+// IsencryptedFlag implements IsencryptedFlag with no arguments.
+func (o CallOption) IsencryptedFlag() []string { return nil }
+
+// MakehybridFlag implements MakehybridFlag with no arguments.
+func (o CallOption) MakehybridFlag() []string { return nil }
+
+// PluginsFlag implements PluginsFlag with no arguments.
+func (o CallOption) PluginsFlag() []string { return nil }
+
+// PmapFlag implements PmapFlag with no arguments.
+func (o CallOption) PmapFlag() []string { return nil }
+
+// ResizeFlag implements ResizeFlag with no arguments.
+func (o CallOption) ResizeFlag() []string { return nil }
+
+// SegmentFlag implements SegmentFlag with no arguments.
+func (o CallOption) SegmentFlag() []string { return nil }
+
+// UdifderezFlag implements UdifderezFlag with no arguments.
+func (o CallOption) UdifderezFlag() []string { return nil }
+
+// UdifrezFlag implements UdifrezFlag with no arguments.
+func (o CallOption) UdifrezFlag() []string { return nil }
+
+// UnmountFlag implements UnmountFlag with no arguments.
+func (o CallOption) UnmountFlag() []string { return nil }
+
+// VerifyFlag implements VerifyFlag with no arguments.
+func (o CallOption) VerifyFlag() []string { return nil }
+
+// internalCall marks an invocation made by the package itself rather than requested by the caller,
+// such as the detach of an image attached by Compare, so that it is neither audited nor confirmed.
+func internalCall() CallOption {
+	return CallOption{apply: func(c *callConfig) { c.internal = true }}
+}
+
+// ErrTimeout is returned when hdiutil is killed because its timeout or the deadline of its context expired.
+// The error also wraps context.DeadlineExceeded.
+var ErrTimeout = errors.New("hdiutil timed out")
+
+// WithTimeout overrides the Client default timeout of the verb class for this call.
+// hdiutil is killed once d elapsed, and the verb fails with ErrTimeout. A zero d disables the timeout.
+func WithTimeout(d time.Duration) CallOption {
+	return CallOption{apply: func(c *callConfig) { c.timeout = &d }}
+}
+
+// WithContext runs the call with ctx. hdiutil is killed if ctx is done before it exits.
+func WithContext(ctx context.Context) CallOption {
+	return CallOption{apply: func(c *callConfig) { c.ctx = ctx }}
+}
+
+// WithOutput copies the standard output and standard error of hdiutil to stdout and stderr as it runs,
+// overriding the writers set by WithOutputWriters for this call. A nil writer discards the stream.
+//
+// It is meant for the diagnostics printed with Verbose and Debug, which are otherwise only parsed or logged.
+// The verbs reporting Progress merge both streams, which are then copied to stderr.
+func WithOutput(stdout, stderr io.Writer) CallOption {
+	return CallOption{apply: func(c *callConfig) { c.output, c.stdout, c.stderr = true, stdout, stderr }}
+}
+
+// Output is the raw output of an hdiutil invocation, captured with WithOutputCapture.
+type Output struct {
+	Stdout []byte
+
+	// Stderr is the standard error, or the merged output of the verbs reporting Progress.
+	Stderr []byte
+}
+
+// WithOutputCapture stores the raw output of hdiutil to out once the call returns, whether it succeeded or failed,
+// such as to keep the messages of a create or detach which are otherwise discarded.
+// out is left empty if hdiutil did not run, such as in dry-run mode.
+func WithOutputCapture(out *Output) CallOption {
+	return CallOption{apply: func(c *callConfig) { c.capture = out }}
+}
+
+// command is an hdiutil invocation.
+type command struct {
+	verb     string
+	target   string
+	targets  []string
+	output   string
+	args     []string
+	stdin    io.Reader
+	progress func(Progress)
+	call     callConfig
+
+	// plist reports whether cmd runs with -plist, in which case the Plist flags given by the caller are redundant.
+	plist bool
+
+	// defaulted reports whether the default flags of the Client were applied to args.
+	defaulted bool
+
+	// shrink reports whether a resize may shrink the image, see Client.resizeShrinks.
+	shrink bool
+
+	// tool reports whether verb is a helper tool, such as diskutil, run by runTool instead of an hdiutil verb.
+	tool bool
+
+	// modifies reports whether a helper tool invocation modifies its target in place, such as adding an APFS volume to an attached image.
+	modifies bool
+
+	// destructive reports whether a helper tool invocation erases data, such as createinstallmedia erasing its volume,
+	// in which case it is audited and confirmed like the destructive hdiutil verbs.
+	destructive bool
+
+	// sudo reports whether a helper tool invocation needs root privileges, in which case it runs with sudo if the Client uses WithSudo.
+	sudo bool
+
+	// unsupported is the options of the flags given to cmd which its verb does not accept, see validateFlags.
+	unsupported []string
+}
+
+// allTargets returns the images or devices cmd operates on.
+// Most verbs have a single target, but attach accepts several images.
+func (cmd *command) allTargets() []string {
+	if len(cmd.targets) > 0 {
+		return cmd.targets
+	}
+	return []string{cmd.target}
+}
+
+// command returns a new invocation of verb with args.
+func (c *Client) command(verb string, args ...string) *command {
+	return &command{verb: verb, args: args}
+}
+
+// plistCommand returns a new invocation of verb with -plist and args, to be run with runPlist.
+func (c *Client) plistCommand(verb string, args ...string) *command {
+	cmd := c.command(verb, append([]string{"-plist"}, args...)...)
+	cmd.plist = true
+	return cmd
+}
+
+// flag adds the arguments args of flag to cmd, or applies flag if it is a CallOption.
+// A Passphrase also becomes the standard input of cmd.
+func (cmd *command) flag(flag interface{}, args []string) {
+	if o, ok := flag.(CallOption); ok {
+		o.apply(&cmd.call)
+		return
+	}
+	if p, ok := flag.(Passphrase); ok {
+		cmd.stdin = p.stdin()
+	}
+	if _, ok := flag.(plist); ok && cmd.plist {
+		return
+	}
+	if !cmd.acceptsFlag(args) {
+		cmd.unsupported = append(cmd.unsupported, args[0])
+	}
+	cmd.args = append(cmd.args, args...)
+}
+
+// run runs cmd and returns its standard output and standard error.
+//
+// If cmd reports progress, the standard output and standard error are streamed to it and
+// the last lines of the output are returned as the standard error.
+func (c *Client) run(cmd *command) (stdout, stderr []byte, err error) {
+	start := time.Now()
+	defer func() {
+		c.logInvocation(cmd, start, stdout, stderr, err)
+		c.auditInvocation(cmd, start, err)
+	}()
+
+	c.applyDefaultFlags(cmd)
+	if err := cmd.validateFlags(); err != nil {
+		return nil, nil, err
+	}
+	if err := c.enforcePolicy(cmd); err != nil {
+		return nil, nil, err
+	}
+	if err := c.enforceReadOnly(cmd); err != nil {
+		return nil, nil, err
+	}
+	if c.dryRun {
+		return nil, nil, c.dryRunError(append([]string{cmd.verb}, cmd.args...))
+	}
+	if err := c.preflight(cmd); err != nil {
+		return nil, nil, err
+	}
+	if err := c.confirmInvocation(cmd); err != nil {
+		return nil, nil, err
+	}
+
+	var combined bytes.Buffer
+	if out := cmd.call.capture; out != nil {
+		defer func() {
+			out.Stdout, out.Stderr = stdout, stderr
+			if cmd.progress != nil && c.runner == nil {
+				out.Stderr = combined.Bytes()
+			}
+		}()
+	}
+
+	ctx, cancel := c.context(&cmd.call)
+	defer cancel()
+	if d := c.timeout(cmd.verb, &cmd.call); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	args := append([]string{cmd.verb}, cmd.args...)
+	runner := ExecRunner{Path: c.hdiutilPath, Env: c.env(), Sudo: c.sudo, Priority: c.priority}
+	stdoutW, stderrW := c.outputWriters(&cmd.call)
+
+	switch {
+	case c.runner != nil:
+		stdout, stderr, err = c.runner.Run(ctx, args, cmd.stdin)
+		if stdoutW != nil {
+			stdoutW.Write(stdout)
+		}
+		if stderrW != nil {
+			stderrW.Write(stderr)
+		}
+		if cmd.progress != nil {
+			replayProgress(ctx, stdout, cmd.progress)
+		}
+	case cmd.progress != nil:
+		progress := cmd.progress
+		if c.logger != nil && cmd.verbose() {
+			progress = func(p Progress) {
+				if p.Message != "" {
+					c.logger.LogAttrs(ctx, slog.LevelDebug, "hdiutil output", slog.String("verb", cmd.verb), slog.String("line", p.Message))
+				}
+				cmd.progress(p)
+			}
+		}
+		var x *exec.Cmd
+		if x, err = runner.command(ctx, args, cmd.stdin); err == nil {
+			out := stderrW
+			if cmd.call.capture != nil {
+				out = teeWriter(&combined, stderrW)
+			}
+			stderr, err = runProgress(ctx, x, progress, out)
+		}
+	default:
+		stdout, stderr, err = runner.run(ctx, args, cmd.stdin, stdoutW, stderrW)
+	}
+	if err != nil && runner.Sudo && c.runner == nil && isSudoAuthFailure(stderr) {
+		err = fmt.Errorf("%w: %w", ErrSudoAuth, err)
+	}
+	if err != nil && ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: %w: %w", ErrTimeout, ctx.Err(), err)
+		} else {
+			err = fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+	if err != nil {
+		if code, ok := findCode(stderr); ok {
+			err = &CodeError{Code: code, Err: err}
+		} else if code, ok := findCode(stdout); ok {
+			err = &CodeError{Code: code, Err: err}
+		}
+		err = newError(cmd, stdout, stderr, err)
+	}
+
+	return stdout, stderr, err
+}
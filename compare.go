@@ -0,0 +1,128 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// compareBlockSize is the size of the blocks compared by Compare, a multiple of the sector size as required by the raw devices.
+const compareBlockSize = 1 << 20
+
+// CompareRange is a byte range of the image data.
+type CompareRange struct {
+	Offset int64
+	Length int64
+}
+
+// CompareReport details the comparison of two images by Compare.
+type CompareReport struct {
+	// ChecksumType, ChecksumA and ChecksumB is the checksum of the data of the two images.
+	ChecksumType ChecksumType
+	ChecksumA    ChecksumValue
+	ChecksumB    ChecksumValue
+
+	// SizeA and SizeB is the size of the data of the two images. They are only set if the checksums differ.
+	SizeA int64
+	SizeB int64
+
+	// BlockSize is the granularity of Ranges.
+	BlockSize int64
+
+	// Ranges is the coalesced ranges of the blocks which differ, including the data past the end of the smaller image.
+	Ranges []CompareRange
+}
+
+// Compare compares the data of the images imageA and imageB, regardless of their image format.
+//
+// The checksums of the images are compared first. If they differ, both images are attached read-only without mounting them
+// and their raw devices are compared block by block, to localize where the images diverge in the report.
+func Compare(imageA, imageB string) (equal bool, detail CompareReport, err error) {
+	return DefaultClient.Compare(imageA, imageB)
+}
+
+// Compare is like the package-level Compare, but runs hdiutil with the configuration of c.
+func (c *Client) Compare(imageA, imageB string) (equal bool, detail CompareReport, err error) {
+	detail.ChecksumType = ChecksumSHA256
+	if detail.ChecksumA, err = c.Checksum(imageA, detail.ChecksumType); err != nil {
+		return false, detail, fmt.Errorf("checksum %s: %w", imageA, err)
+	}
+	if detail.ChecksumB, err = c.Checksum(imageB, detail.ChecksumType); err != nil {
+		return false, detail, fmt.Errorf("checksum %s: %w", imageB, err)
+	}
+	if detail.ChecksumA.Equal(detail.ChecksumB) {
+		return true, detail, nil
+	}
+
+	a, err := c.Attach(imageA, AttachReadonly, AttachNoMount, AttachNoVerify)
+	if err != nil {
+		return false, detail, fmt.Errorf("attach %s: %w", imageA, err)
+	}
+	defer c.Detach(a.DeviceNode().String(), DetachForce, internalCall())
+
+	b, err := c.Attach(imageB, AttachReadonly, AttachNoMount, AttachNoVerify)
+	if err != nil {
+		return false, detail, fmt.Errorf("attach %s: %w", imageB, err)
+	}
+	defer c.Detach(b.DeviceNode().String(), DetachForce, internalCall())
+
+	if err := compareDevices(a.DeviceNode().Raw().String(), b.DeviceNode().Raw().String(), &detail); err != nil {
+		return false, detail, err
+	}
+
+	return len(detail.Ranges) == 0, detail, nil
+}
+
+// compareDevices compares the devices a and b block by block, recording the sizes and the differing ranges in r.
+func compareDevices(a, b string, r *CompareReport) error {
+	fa, err := os.Open(a)
+	if err != nil {
+		return err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return err
+	}
+	defer fb.Close()
+
+	r.BlockSize = compareBlockSize
+	bufA := make([]byte, compareBlockSize)
+	bufB := make([]byte, compareBlockSize)
+	for off := int64(0); ; off += compareBlockSize {
+		na, err := io.ReadFull(fa, bufA)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("read %s: %w", a, err)
+		}
+		nb, err := io.ReadFull(fb, bufB)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("read %s: %w", b, err)
+		}
+		r.SizeA += int64(na)
+		r.SizeB += int64(nb)
+		if na == 0 && nb == 0 {
+			return nil
+		}
+
+		if na != nb || !bytes.Equal(bufA[:na], bufB[:nb]) {
+			r.addRange(off, int64(max(na, nb)))
+		}
+		if na < compareBlockSize && nb < compareBlockSize {
+			return nil
+		}
+	}
+}
+
+// addRange adds the differing range at off of length n to r, merging it with the last range if adjacent.
+func (r *CompareReport) addRange(off, n int64) {
+	if last := len(r.Ranges) - 1; last >= 0 && r.Ranges[last].Offset+r.Ranges[last].Length == off {
+		r.Ranges[last].Length += n
+		return
+	}
+	r.Ranges = append(r.Ranges, CompareRange{Offset: off, Length: n})
+}
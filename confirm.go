@@ -0,0 +1,43 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfirmFunc is consulted before a destructive operation is executed.
+// The operation is canceled if it returns a non-nil error.
+type ConfirmFunc func(op Operation) error
+
+// ErrNotConfirmed is wrapped by the errors of the destructive operations canceled by a ConfirmFunc.
+var ErrNotConfirmed = errors.New("operation not confirmed")
+
+// WithConfirm consults fn before every destructive invocation of the Client, as defined by WithAuditWriter,
+// enabling interactive "are you sure" flows and policy prompts.
+//
+// Only the operations requested by the caller are confirmed: the invocations the package makes to clean up,
+// such as the detach of the images attached by Compare or ToISO, are not.
+func WithConfirm(fn ConfirmFunc) ClientOption {
+	return func(c *Client) {
+		c.confirm = fn
+	}
+}
+
+// confirmInvocation consults the Client ConfirmFunc if cmd is destructive.
+func (c *Client) confirmInvocation(cmd *command) error {
+	if c.confirm == nil {
+		return nil
+	}
+	op, ok := cmd.operation()
+	if !ok {
+		return nil
+	}
+	if err := c.confirm(op); err != nil {
+		return fmt.Errorf("%s %s: %w: %w", op.Verb, op.Target, ErrNotConfirmed, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	deny := errors.New("denied")
+	var confirmed []Operation
+	var ran int
+	c := NewClient(
+		WithConfirm(func(op Operation) error {
+			confirmed = append(confirmed, op)
+			return deny
+		}),
+		WithRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+			ran++
+			return nil, nil, nil
+		})),
+	)
+
+	if err := c.Detach("/dev/disk4", DetachForce, internalCall()); err != nil {
+		t.Errorf("internal detach: %v", err)
+	}
+	if err := c.Detach("/dev/disk4"); err != nil {
+		t.Errorf("detach: %v", err)
+	}
+	if err := c.Detach("/dev/disk5", DetachForce); !errors.Is(err, ErrNotConfirmed) || !errors.Is(err, deny) {
+		t.Errorf("forced detach: %v, want ErrNotConfirmed", err)
+	}
+
+	if len(confirmed) != 1 || confirmed[0].Target != "/dev/disk5" {
+		t.Errorf("confirmed %+v, want the forced detach of /dev/disk5", confirmed)
+	}
+	if ran != 2 {
+		t.Errorf("ran hdiutil %d times, want 2", ran)
+	}
+}
@@ -4,18 +4,19 @@
 
 package hdiutil
 
-import "os/exec"
+import "fmt"
 
-// formatFlag implements a hdiutil convert command format flag interface.
-type formatFlag interface {
-	formatFlag() []string
+// FormatFlag is a hdiutil convert command format flag, returning its command-line arguments.
+type FormatFlag interface {
+	FormatFlag() []string
 }
 
-type convertFormot int
+// Format is a disk image format, as given to convert -format.
+type Format int
 
 const (
 	// ConvertUDRW UDIF read/write image.
-	ConvertUDRW convertFormot = 1 << iota
+	ConvertUDRW Format = 1 << iota
 	// ConvertUDRO UDIF read-only image.
 	ConvertUDRO
 	// ConvertUDCO UDIF ADC-compressed image.
@@ -50,56 +51,160 @@ const (
 	ConvertDC42
 )
 
-// convertFlag implements a hdiutil convert command flag interface.
-type convertFlag interface {
-	convertFlag() []string
+func (c Format) String() string {
+	switch c {
+	case ConvertUDRW:
+		return "UDRW"
+	case ConvertUDRO:
+		return "UDRO"
+	case ConvertUDCO:
+		return "UDCO"
+	case ConvertUDZO:
+		return "UDZO"
+	case ConvertULFO:
+		return "ULFO"
+	case ConvertUDBZ:
+		return "UDBZ"
+	case ConvertUDTO:
+		return "UDTO"
+	case ConvertUDSP:
+		return "UDSP"
+	case ConvertUDSB:
+		return "UDSB"
+	case ConvertUFBI:
+		return "UFBI"
+	case ConvertUDRo:
+		return "UDRo"
+	case ConvertUDCo:
+		return "UDCo"
+	case ConvertRdWr:
+		return "RdWr"
+	case ConvertRdxx:
+		return "Rdxx"
+	case ConvertROCo:
+		return "ROCo"
+	case ConvertRken:
+		return "Rken"
+	case ConvertDC42:
+		return "DC42"
+	default:
+		return ""
+	}
+}
+
+func (c Format) FormatFlag() []string { return stringFlag("format", c.String()) }
+
+// ConvertFlag is a hdiutil convert command flag, returning its command-line arguments.
+type ConvertFlag interface {
+	ConvertFlag() []string
 }
 
 // ConvertAlign default is 4 (2K).
 type ConvertAlign int
 
-func (c ConvertAlign) convertFlag() []string { return intFlag("align", int(c)) }
+func (c ConvertAlign) ConvertFlag() []string { return intFlag("align", int(c)) }
 
 type convertPmap bool
 
-func (c convertPmap) convertFlag() []string { return boolFlag("pmap", bool(c)) }
+func (c convertPmap) ConvertFlag() []string { return boolFlag("pmap", bool(c)) }
 
 // ConvertSegmentSize specify segmentation into size_spec-sized segments as outfile is being written.
 //
 // The default size_spec when ConvertSegmentSize is specified alone is 2*1024*1024 (1 GB worth of sectors) for UDTO images and 4*1024*1024 (2 GB segments) for all other image types.
 //
-// size_spec(string) can also be specified ??b|??k|??m|??g|??t|??p|??e like create's CreateSize flag.
+// size_spec(string) can also be specified ??b|??k|??m|??g|??t|??p|??e like create's CreateSize flag, or as any size accepted by ParseSize.
 type ConvertSegmentSize string
 
-func (c ConvertSegmentSize) convertFlag() []string { return stringFlag("segmentSize", string(c)) }
+func (c ConvertSegmentSize) ConvertFlag() []string {
+	return stringFlag("segmentSize", hdiutilSize(string(c)))
+}
 
 // ConvertTasks when converting an image into a compressed format, specify the number of threads to use for the compression operation.
 //
 // The default is the number of processors active in the current system.
 type ConvertTasks int
 
-func (c ConvertTasks) convertFlag() []string { return intFlag("tasks", int(c)) }
+func (c ConvertTasks) ConvertFlag() []string { return intFlag("tasks", int(c)) }
 
 const (
 	// ConvertPmap add partition map.
 	ConvertPmap convertPmap = true
 )
 
-// Convert convert image to type format and write the result to outfile.
-func Convert(image string, format formatFlag, outfile string, flags ...convertFlag) error {
-	cmd := exec.Command(hdiutilPath, "convert", image)
-	cmd.Args = append(cmd.Args, format.formatFlag()...)
-	cmd.Args = append(cmd.Args, outfile)
-	if len(flags) > 0 {
-		for _, flag := range flags {
-			cmd.Args = append(cmd.Args, flag.convertFlag()...)
-		}
+// ConvertResult is the result of converting an image.
+type ConvertResult struct {
+	// Outputs is the paths of the files written, as reported by convert -plist.
+	// hdiutil appends the extension of the format to outfile, and a segmented output has a file per segment, the first segment first.
+	Outputs []string
+
+	// Format is the name of the output format, such as UDZO.
+	Format string
+
+	// Segments is the files of the output, with their sizes, as found on disk. It has a single file unless the output is segmented,
+	// see ConvertSegmentSize.
+	Segments []SegmentFile
+
+	// Size is the total size of the output files, in bytes.
+	Size int64
+
+	// Raw is the property list output of hdiutil convert.
+	Raw []byte
+}
+
+// ParseConvertPlist decodes the hdiutil convert -plist output data.
+// Only Outputs and Raw are set, as the output does not report the format and the size.
+func ParseConvertPlist(data []byte) (ConvertResult, error) {
+	outputs, err := ParseCreatePlist(data)
+	if err != nil {
+		return ConvertResult{}, err
 	}
+	return ConvertResult{Outputs: outputs, Raw: rawPlist(data)}, nil
+}
+
+// Convert convert image to type format and write the result to outfile. The returns written files and error.
+func Convert(image string, format FormatFlag, outfile string, flags ...ConvertFlag) (ConvertResult, error) {
+	return DefaultClient.Convert(image, format, outfile, flags...)
+}
 
-	err := cmd.Run()
+// Convert is like the package-level Convert, but runs hdiutil with the configuration of c.
+func (c *Client) Convert(image string, format FormatFlag, outfile string, flags ...ConvertFlag) (ConvertResult, error) {
+	formatArgs := format.FormatFlag()
+	cmd := c.plistCommand("convert", image)
+	cmd.target = image
+	cmd.output = outfile
+	cmd.args = append(cmd.args, formatArgs...)
+	cmd.args = append(cmd.args, "-o", outfile)
+	for _, flag := range flags {
+		cmd.flag(flag, flag.ConvertFlag())
+	}
+
+	out, err := c.runPlist(cmd)
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	result, err := ParseConvertPlist(out)
 	if err != nil {
-		return err
+		return ConvertResult{}, err
+	}
+	if len(formatArgs) > 0 {
+		result.Format = formatArgs[len(formatArgs)-1]
+	}
+	if len(result.Outputs) == 0 {
+		return result, fmt.Errorf("no output file in hdiutil convert output for %s", outfile)
+	}
+	if result.Segments, err = SegmentFiles(result.Outputs[0]); err != nil {
+		return result, err
+	}
+	written := make(map[string]bool)
+	for _, seg := range result.Segments {
+		written[seg.Path] = true
+		result.Size += seg.Size
+	}
+	for _, output := range result.Outputs {
+		if !written[output] {
+			return result, fmt.Errorf("output file %s of hdiutil convert not found next to %s", output, result.Outputs[0])
+		}
 	}
 
-	return nil
+	return result, nil
 }
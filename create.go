@@ -4,29 +4,34 @@
 
 package hdiutil
 
-import "os/exec"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
-// sizeFlag implements a hdiutil create command size flag interface.
-type sizeFlag interface {
-	sizeFlag() []string
+// SizeFlag is a hdiutil create command size flag, returning its command-line arguments.
+type SizeFlag interface {
+	SizeFlag() []string
 }
 
 // CreateSize specify the size of the image in the style of mkfile(8) with the addition of tera-, peta-, and exa-bytes sizes.
 //
 // The larger sizes are useful for large sparse images.
+// The human-readable sizes accepted by ParseSize, such as "1.5GiB" or "500MB", are converted to the hdiutil syntax.
 type CreateSize string
 
-func (c CreateSize) sizeFlag() []string { return stringFlag("size", string(c)) }
+func (c CreateSize) SizeFlag() []string { return stringFlag("size", hdiutilSize(string(c))) }
 
 // CreateSectors specify the size of the image file in 512-byte sectors.
 type CreateSectors int
 
-func (c CreateSectors) sizeFlag() []string { return intFlag("sectors", int(c)) }
+func (c CreateSectors) SizeFlag() []string { return intFlag("sectors", int(c)) }
 
 // CreateMegabytes specify the size of the image file in megabytes (1024*1024 bytes).
 type CreateMegabytes int
 
-func (c CreateMegabytes) sizeFlag() []string { return intFlag("megabytes", int(c)) }
+func (c CreateMegabytes) SizeFlag() []string { return intFlag("megabytes", int(c)) }
 
 // CreateSrcfolder copies file-by-file the contents of source into image, creating a fresh (theoretically defragmented) filesystem on the destination.
 //
@@ -37,12 +42,12 @@ func (c CreateMegabytes) sizeFlag() []string { return intFlag("megabytes", int(c
 // CreateSrcfolder can be specified more than once, in which case the image volume will be populated at the top level with a copy of each specified filesystem object.
 type CreateSrcfolder string
 
-func (c CreateSrcfolder) sizeFlag() []string { return stringFlag("srcfolder", string(c)) }
+func (c CreateSrcfolder) SizeFlag() []string { return stringFlag("srcfolder", string(c)) }
 
 // CreateSrcdir is a synonym to CreateSrcfolder.
 type CreateSrcdir CreateSrcfolder
 
-func (c CreateSrcdir) sizeFlag() []string { return stringFlag("srcdir", string(c)) }
+func (c CreateSrcdir) SizeFlag() []string { return stringFlag("srcdir", string(c)) }
 
 // CreateSrcdevice specifies that the blocks of device should be used to create a new image.
 //
@@ -51,20 +56,20 @@ func (c CreateSrcdir) sizeFlag() []string { return stringFlag("srcdir", string(c
 // One way around this problem is to write over the files in question in the hopes that the drive will remap the bad blocks.
 // Data will be lost, but the image creation operation will subsequently succeed.
 //
-// Filesystem options (like createFS, CreateVolname, CreateStretch, or CreateSize) are invalid and ignored when using CreateSrcdevice.
+// Filesystem options (like CreateFS, CreateVolname, CreateStretch, or CreateSize) are invalid and ignored when using CreateSrcdevice.
 type CreateSrcdevice string
 
-func (c CreateSrcdevice) sizeFlag() []string { return stringFlag("srcdevice", string(c)) }
+func (c CreateSrcdevice) SizeFlag() []string { return stringFlag("srcdevice", string(c)) }
 
-// createFlag implements a hdiutil create command flag interface.
-type createFlag interface {
-	createFlag() []string
+// CreateFlag is a hdiutil create command flag, returning its command-line arguments.
+type CreateFlag interface {
+	CreateFlag() []string
 }
 
 // CreateAlign specifies a size to which the final data partition will be aligned. The default is 4K.
 type CreateAlign int
 
-func (c CreateAlign) createFlag() []string { return intFlag("align", int(c)) }
+func (c CreateAlign) CreateFlag() []string { return intFlag("align", int(c)) }
 
 type createType int
 
@@ -90,13 +95,14 @@ func (c createType) String() string {
 	}
 }
 
-func (c createType) createFlag() []string { return stringFlag("type", c.String()) }
+func (c createType) CreateFlag() []string { return stringFlag("type", c.String()) }
 
-type createFS int
+// CreateFS is the filesystem of the image created by Create, such as CreateHFSPlus.
+type CreateFS int
 
 const (
 	// CreateHFSPlus provides the HFS+.
-	CreateHFSPlus createFS = 1 << iota
+	CreateHFSPlus CreateFS = 1 << iota
 	// CreateHFSPlusJ provides the HFS+J.
 	CreateHFSPlusJ
 	// CreateJHFSPlus provides the JHFS+.
@@ -107,6 +113,8 @@ const (
 	CreateJHFSPlusX
 	// CreateAPFS provides the APFS.
 	CreateAPFS
+	// CreateCaseSensitiveAPFS provides the case-sensitive APFS.
+	CreateCaseSensitiveAPFS
 	// CreateFAT32 provides the FAT32.
 	CreateFAT32
 	// CreateExFAT provides the ExFAT.
@@ -115,7 +123,7 @@ const (
 	CreateUDF
 )
 
-func (c createFS) String() string {
+func (c CreateFS) String() string {
 	switch c {
 	case CreateHFSPlus:
 		return "HFS+"
@@ -129,6 +137,8 @@ func (c createFS) String() string {
 		return "JHFS+X"
 	case CreateAPFS:
 		return "APFS"
+	case CreateCaseSensitiveAPFS:
+		return "Case-sensitive APFS"
 	case CreateFAT32:
 		return "FAT32"
 	case CreateExFAT:
@@ -140,51 +150,101 @@ func (c createFS) String() string {
 	}
 }
 
-func (c createFS) createFlag() []string { return stringFlag("fs", c.String()) }
+func (c CreateFS) CreateFlag() []string { return stringFlag("fs", c.String()) }
 
 // CreateVolname the newly-created filesystem will be named volname.
 //
 // The default depends the filesystem being used, The default volume name in both HFS+ and APFS is `untitled'.
 //
 // CreateVolname is invalid and ignored when using CreateSrcdevice.
+// The FAT32 and ExFAT labels are checked with ValidateVolumeLabel, see also CreateNormalizeLabel.
 type CreateVolname string
 
-func (c CreateVolname) createFlag() []string { return stringFlag("volname", string(c)) }
+func (c CreateVolname) CreateFlag() []string { return stringFlag("volname", string(c)) }
 
 // CreateUID the root of the newly-created volume will be owned by the given numeric user id. 99 maps to the magic 'unknown' user.
 type CreateUID int
 
-func (c CreateUID) createFlag() []string { return intFlag("uid", int(c)) }
+func (c CreateUID) CreateFlag() []string { return intFlag("uid", int(c)) }
 
 // CreateGID the root of the newly-created volume will be owned by the given numeric group id. 99 maps to 'unknown'.
 type CreateGID int
 
-func (c CreateGID) createFlag() []string { return intFlag("gid", int(c)) }
+func (c CreateGID) CreateFlag() []string { return intFlag("gid", int(c)) }
 
 // CreateMode the root of the newly-created volume will have mode (in octal) mode.
 //
 // The default mode is determined by the filesystem's newfs unless CreateSrcfolder is specified, in which case the default mode is derived from the specified filesystem object.
 type CreateMode string
 
-func (c CreateMode) createFlag() []string { return stringFlag("mode", string(c)) }
+func (c CreateMode) CreateFlag() []string { return stringFlag("mode", string(c)) }
 
 type createAutostretch bool
 
-func (c createAutostretch) createFlag() []string { return boolNoFlag("autostretch", bool(c)) }
+func (c createAutostretch) CreateFlag() []string { return boolNoFlag("autostretch", bool(c)) }
 
 // CreateStretch initializes HFS+ filesystem data such that it can later be stretched on older systems (which could only stretch within predefined limits) using hdiutil resize or by asr(8). max_stretch(int) is specified like CreateSize.
 //
 // CreateStretch is invalid and ignored when using CreateSrcdevice.
 type CreateStretch int
 
-func (c CreateStretch) createFlag() []string { return intFlag("stretch", int(c)) }
+func (c CreateStretch) CreateFlag() []string { return intFlag("stretch", int(c)) }
 
-// CreateFSArgs additional arguments to pass to whichever newfs program is implied by createFS.
+// CreateFSArgs additional arguments to pass to whichever newfs program is implied by CreateFS.
 //
 // As an example with HFS+, newfs_hfs(8) has a number of options that can control the amount of space used by the filesystem's data structures.
-type CreateFSArgs []string
+// The arguments are built with the FSArg helpers, such as
+//
+//	CreateFSArgs{HFSBlockSize(4096), HFSJournalSize("16m"), RawFSArg("-c", "c=64")}
+//
+// hdiutil splits the arguments on white space, so they can not contain spaces.
+type CreateFSArgs []FSArg
+
+func (c CreateFSArgs) CreateFlag() []string {
+	var args []string
+	for _, a := range c {
+		args = append(args, a...)
+	}
+	return stringFlag("fsargs", strings.Join(args, " "))
+}
+
+// FSArg is an argument of the newfs program, with its value if any.
+type FSArg []string
+
+// RawFSArg passes args to the newfs program as is.
+func RawFSArg(args ...string) FSArg { return FSArg(args) }
+
+// HFSBlockSize sets the allocation block size of newfs_hfs, in bytes.
+func HFSBlockSize(n int) FSArg { return FSArg{"-b", strconv.Itoa(n)} }
+
+// HFSJournalSize sets the journal size of newfs_hfs, such as "16m". An empty size uses the default journal size.
+//
+// The size is given to newfs_hfs in bytes, as it does not accept the sector suffix of the hdiutil sizes.
+func HFSJournalSize(size string) FSArg {
+	if size == "" {
+		return FSArg{"-J"}
+	}
+	n, err := ParseSize(size)
+	if err != nil {
+		return FSArg{"-J", size}
+	}
+	return FSArg{"-J", strconv.FormatInt(int64(n), 10)}
+}
+
+// HFSCaseSensitive makes newfs_hfs create a case-sensitive HFSX filesystem.
+func HFSCaseSensitive() FSArg { return FSArg{"-s"} }
+
+// MSDOSClusterSize sets the number of sectors per cluster of newfs_msdos, a power of 2.
+func MSDOSClusterSize(sectors int) FSArg { return FSArg{"-c", strconv.Itoa(sectors)} }
+
+// MSDOSFATType sets the FAT type of newfs_msdos, 12, 16 or 32.
+func MSDOSFATType(bits int) FSArg { return FSArg{"-F", strconv.Itoa(bits)} }
 
-func (c CreateFSArgs) createFlag() []string { return stringSliceFlag("fsargs", c) }
+// MSDOSSectorSize sets the number of bytes per sector of newfs_msdos.
+func MSDOSSectorSize(n int) FSArg { return FSArg{"-S", strconv.Itoa(n)} }
+
+// APFSBlockSize sets the block size of newfs_apfs, in bytes.
+func APFSBlockSize(n int) FSArg { return FSArg{"-b", strconv.Itoa(n)} }
 
 // CreateLayout specify the partition layout of the image.
 //
@@ -194,64 +254,65 @@ func (c CreateFSArgs) createFlag() []string { return stringSliceFlag("fsargs", c
 // 'SPUD' causes a DDM and an Apple Partition Scheme partition map with a single entry to be written. 'GPTSPUD' creates a similar image but with a GUID Partition Scheme map instead.
 // When attached, multiple /dev entries will be created, with either slice 1 (GPT) or slice 2 (APM) as the data partition. (e.g. /dev/disk1, /dev/disk1s1, /dev/disk1s2).
 //
-// Unless overridden by createFS, the default layout is 'GPTSPUD' (PPC systems used 'SPUD' prior to Mac OS X 10.6). Other layouts include 'MBRSPUD' and 'ISOCD'. create -help lists all supported layouts.
+// Unless overridden by CreateFS, the default layout is 'GPTSPUD' (PPC systems used 'SPUD' prior to Mac OS X 10.6). Other layouts include 'MBRSPUD' and 'ISOCD'. create -help lists all supported layouts.
 type CreateLayout string
 
-func (c CreateLayout) createFlag() []string { return stringFlag("layout", string(c)) }
+func (c CreateLayout) CreateFlag() []string { return stringFlag("layout", string(c)) }
 
 // CreateLibrary specify an alternate layout library. The default is MediaKit's MKDrivers.bundle.
 type CreateLibrary string
 
-func (c CreateLibrary) createFlag() []string { return stringFlag("library", string(c)) }
+func (c CreateLibrary) CreateFlag() []string { return stringFlag("library", string(c)) }
 
-// CreatePartitionType change the type of partition in a single-partition disk image. The default is Apple_HFS unless createFS implies otherwise.
+// CreatePartitionType change the type of partition in a single-partition disk image. The default is Apple_HFS unless CreateFS implies otherwise.
 type CreatePartitionType string
 
-func (c CreatePartitionType) createFlag() []string { return stringFlag("partitionType", string(c)) }
+func (c CreatePartitionType) CreateFlag() []string { return stringFlag("partitionType", string(c)) }
 
 type createOV bool
 
-func (c createOV) createFlag() []string { return boolFlag("ov", bool(c)) }
+func (c createOV) CreateFlag() []string { return boolFlag("ov", bool(c)) }
 
 type createAttach bool
 
-func (c createAttach) createFlag() []string { return boolFlag("attach", bool(c)) }
+func (c createAttach) CreateFlag() []string { return boolFlag("attach", bool(c)) }
 
 // CreateFormat specify the final image format. The default when a source is specified is UDZO. CreateFormat can be any of the format parameters used by convert.
 type CreateFormat string
 
-func (c CreateFormat) createFlag() []string { return stringFlag("format", string(c)) }
+func (c CreateFormat) CreateFlag() []string { return stringFlag("format", string(c)) }
 
 // CreateSegmentSize specify that the image should be written in segments no bigger than size_spec (which follows CreateSize conventions).
+// SegmentFiles lists the segments of the created image.
 type CreateSegmentSize int
 
-func (c CreateSegmentSize) createFlag() []string { return intFlag("segmentSize", int(c)) }
+func (c CreateSegmentSize) CreateFlag() []string { return intFlag("segmentSize", int(c)) }
 
 type createCrossdev bool
 
-func (c createCrossdev) createFlag() []string { return boolNoFlag("crossdev", bool(c)) }
+func (c createCrossdev) CreateFlag() []string { return boolNoFlag("crossdev", bool(c)) }
 
 type createScrub bool
 
-func (c createScrub) createFlag() []string { return boolNoFlag("scrub", bool(c)) }
+func (c createScrub) CreateFlag() []string { return boolNoFlag("scrub", bool(c)) }
 
 type createAnyowners bool
 
-func (c createAnyowners) createFlag() []string { return boolNoFlag("anyowners", bool(c)) }
+func (c createAnyowners) CreateFlag() []string { return boolNoFlag("anyowners", bool(c)) }
 
 type createSkipunreadable bool
 
-func (c createSkipunreadable) createFlag() []string { return boolFlag("skipunreadable", bool(c)) }
+func (c createSkipunreadable) CreateFlag() []string { return boolFlag("skipunreadable", bool(c)) }
 
 type createAtomic bool
 
-func (c createAtomic) createFlag() []string { return boolFlag("atomic", bool(c)) }
+func (c createAtomic) CreateFlag() []string { return boolFlag("atomic", bool(c)) }
 
 // CreateCopyuid perform the copy as the given user. Requires root privilege.
 // If user can't read or create files with the needed owners, CreateAnyowners or CreateSkipunreadable must be used to prevent the operation from failing.
 type CreateCopyuid string
 
-func (c CreateCopyuid) createFlag() []string { return stringFlag("copyuid", string(c)) }
+func (c CreateCopyuid) CreateFlag() []string { return stringFlag("copyuid", string(c)) }
 
 const (
 	// CreateAutostretch do suppress automatically making backwards-compatible stretchable volumes when the volume size crosses the auto-stretch-size threshold (default: 256 MB). See also asr(8).
@@ -263,7 +324,7 @@ const (
 	// CreateOV overwrite an existing file. The default is not to overwrite existing files.
 	CreateOV createOV = true
 
-	// CreateAttach the image after creating it. If no filesystem is specified via createFS, the attach will fail per the default attach createMount required behavior.
+	// CreateAttach the image after creating it. If no filesystem is specified via CreateFS, the attach will fail per the default attach createMount required behavior.
 	CreateAttach createAttach = true
 
 	// CreateCrossdev do cross device boundaries on the source filesystem.
@@ -294,21 +355,41 @@ const (
 	CreateNoAtomic createAtomic = false
 )
 
-// Create create a new image of the given size or from the provided data.
-func Create(image string, sizeSpec sizeFlag, flags ...createFlag) error {
-	cmd := exec.Command(hdiutilPath, "create")
-	cmd.Args = append(cmd.Args, sizeSpec.sizeFlag()...)
-	cmd.Args = append(cmd.Args, image)
-	if len(flags) > 0 {
-		for _, flag := range flags {
-			cmd.Args = append(cmd.Args, flag.createFlag()...)
-		}
+// Create create a new image of the given size or from the provided data. The returns created image path and error.
+//
+// hdiutil appends the extension of the image type to image if it is missing, such as .dmg or .sparsebundle,
+// so the returned path is the one reported by create -plist.
+func Create(image string, sizeSpec SizeFlag, flags ...CreateFlag) (string, error) {
+	return DefaultClient.Create(image, sizeSpec, flags...)
+}
+
+// Create is like the package-level Create, but runs hdiutil with the configuration of c.
+func (c *Client) Create(image string, sizeSpec SizeFlag, flags ...CreateFlag) (string, error) {
+	flags, err := checkVolumeLabel(flags)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := c.plistCommand("create")
+	cmd.target = image
+	cmd.output = image
+	cmd.args = append(cmd.args, sizeSpec.SizeFlag()...)
+	cmd.args = append(cmd.args, image)
+	for _, flag := range flags {
+		cmd.flag(flag, flag.CreateFlag())
 	}
 
-	err := cmd.Run()
+	out, err := c.runPlist(cmd)
 	if err != nil {
-		return err
+		return "", err
+	}
+	paths, err := ParseCreatePlist(out)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no image path in hdiutil create output for %s", image)
 	}
 
-	return nil
+	return paths[0], nil
 }
@@ -0,0 +1,24 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHFSJournalSize(t *testing.T) {
+	for size, want := range map[string]FSArg{
+		"":     {"-J"},
+		"16m":  {"-J", "16777216"},
+		"1024": {"-J", "1024"},
+		"16b":  {"-J", "8192"},
+		"big":  {"-J", "big"},
+	} {
+		if got := HFSJournalSize(size); !reflect.DeepEqual(got, want) {
+			t.Errorf("HFSJournalSize(%q) = %q, want %q", size, got, want)
+		}
+	}
+}
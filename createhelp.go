@@ -0,0 +1,114 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SupportedLayouts returns the partition layouts hdiutil create accepts on this host, such as GPTSPUD, to validate a CreateLayout.
+func SupportedLayouts() ([]CreateLayout, error) {
+	return DefaultClient.SupportedLayouts()
+}
+
+// SupportedLayouts is like the package-level SupportedLayouts, but runs hdiutil with the configuration of c.
+func (c *Client) SupportedLayouts() ([]CreateLayout, error) {
+	help, err := c.createHelp()
+	if err != nil {
+		return nil, err
+	}
+	var layouts []CreateLayout
+	for _, l := range parseHelpList(help, layoutsRe) {
+		layouts = append(layouts, CreateLayout(l))
+	}
+	if len(layouts) == 0 {
+		return nil, fmt.Errorf("no layouts in hdiutil create -help output")
+	}
+	return layouts, nil
+}
+
+// SupportedFilesystems returns the filesystems hdiutil create can format on this host, such as "HFS+" or "APFS", as given to -fs.
+func SupportedFilesystems() ([]string, error) {
+	return DefaultClient.SupportedFilesystems()
+}
+
+// SupportedFilesystems is like the package-level SupportedFilesystems, but runs hdiutil with the configuration of c.
+func (c *Client) SupportedFilesystems() ([]string, error) {
+	help, err := c.createHelp()
+	if err != nil {
+		return nil, err
+	}
+	filesystems := parseHelpList(help, filesystemsRe)
+	if len(filesystems) == 0 {
+		return nil, fmt.Errorf("no filesystems in hdiutil create -help output")
+	}
+	return filesystems, nil
+}
+
+// createHelp returns the hdiutil create -help output. hdiutil prints the usage on standard error and may exit non-zero.
+func (c *Client) createHelp() ([]byte, error) {
+	cmd := c.command("create", "-help")
+
+	stdout, stderr, err := c.run(cmd)
+	help := append(stdout, stderr...)
+	if err != nil && len(help) == 0 {
+		return nil, err
+	}
+	return help, nil
+}
+
+var (
+	layoutsRe     = regexp.MustCompile(`(?i)\blayouts?\b[^:]*:\s*(.*)$`)
+	filesystemsRe = regexp.MustCompile(`(?i)\bfile ?systems?\b[^:]*:\s*(.*)$`)
+)
+
+// parseHelpList returns the list introduced by the heading matching re in the help output, such as
+//
+//	Supported layouts: SPUD, GPTSPUD, NONE
+//	Supported filesystems:
+//	    HFS+
+//	    Case-sensitive APFS
+//
+// The items follow the heading on the same line or on the following indented lines, separated by commas or line ends,
+// up to a blank or unindented line.
+func parseHelpList(help []byte, re *regexp.Regexp) []string {
+	var (
+		items   []string
+		inList  bool
+		seen    = make(map[string]bool)
+		addLine = func(s string) {
+			for _, item := range strings.Split(s, ",") {
+				item = strings.TrimSpace(item)
+				if item != "" && !seen[item] {
+					seen[item] = true
+					items = append(items, item)
+				}
+			}
+		}
+	)
+
+	sc := bufio.NewScanner(bytes.NewReader(help))
+	for sc.Scan() {
+		line := sc.Text()
+		if inList {
+			if strings.TrimSpace(line) == "" || (line[0] != ' ' && line[0] != '\t') {
+				inList = false
+			} else {
+				addLine(line)
+				continue
+			}
+		}
+		if m := re.FindStringSubmatch(line); m != nil && !strings.HasPrefix(strings.TrimSpace(line), "-") {
+			addLine(m[1])
+			inList = true
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// CreateSpec specifies an image created by CreateMany.
+type CreateSpec struct {
+	// Image is the path of the created image.
+	Image string
+
+	// Source is the folder copied into the image, as with CreateSrcfolder.
+	Source string
+
+	// Prepare, if not nil, customizes a private staging copy of Source before the image is created, such as writing per-tenant configuration files.
+	// The staging copy is an APFS clone of Source, so it is cheap to make and Source itself is never modified.
+	Prepare func(dir string) error
+
+	// Flags is the additional create flags of the image.
+	Flags []CreateFlag
+}
+
+// CreateMany creates the images of specs, running at most concurrency hdiutil create at once.
+//
+// It is meant to build many images from the same source folder, such as per-tenant images.
+// The specs with a Prepare function are staged in a clone of their Source in the Client temporary directory (see WithTempDir),
+// which should be on the same APFS volume as the sources to make the clones free. The staging copies are removed once the image is created.
+//
+// Once ctx is done, no more image is started and the running hdiutil processes are killed.
+// CreateMany returns the errors of all failed images joined, each prefixed with the image path.
+func CreateMany(ctx context.Context, specs []CreateSpec, concurrency int) error {
+	return DefaultClient.CreateMany(ctx, specs, concurrency)
+}
+
+// CreateMany is like the package-level CreateMany, but runs hdiutil with the configuration of c.
+func (c *Client) CreateMany(ctx context.Context, specs []CreateSpec, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, spec := range specs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%s: %w", spec.Image, ctx.Err()))
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(spec CreateSpec) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := c.createOne(ctx, spec); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", spec.Image, err))
+				mu.Unlock()
+			}
+		}(spec)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// createOne creates the image of spec for CreateMany.
+func (c *Client) createOne(ctx context.Context, spec CreateSpec) error {
+	source := spec.Source
+	if spec.Prepare != nil {
+		staging, err := os.MkdirTemp(c.tempDir(), "hdiutil-staging")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(staging)
+
+		// keep the source folder name, as it becomes the default volume name.
+		source = filepath.Join(staging, filepath.Base(spec.Source))
+		if err := cloneTree(ctx, spec.Source, source); err != nil {
+			return err
+		}
+		if err := spec.Prepare(source); err != nil {
+			return fmt.Errorf("prepare: %w", err)
+		}
+	}
+
+	flags := append([]CreateFlag{WithContext(ctx)}, spec.Flags...)
+	_, err := c.Create(spec.Image, CreateSrcfolder(source), flags...)
+	return err
+}
+
+// cloneTree copies the src directory tree to dst, using APFS clones when src and dst are on the same APFS volume.
+func cloneTree(ctx context.Context, src, dst string) error {
+	if out, err := exec.CommandContext(ctx, "cp", "-c", "-R", "-p", src, dst).CombinedOutput(); err != nil {
+		// cp -c fails if clones are unsupported, such as across volumes, so fall back to a regular copy.
+		os.RemoveAll(dst)
+		if out2, err2 := exec.CommandContext(ctx, "cp", "-R", "-p", src, dst).CombinedOutput(); err2 != nil {
+			return fmt.Errorf("copy %s: %w: %s%s", src, err2, out, out2)
+		}
+	}
+	return nil
+}
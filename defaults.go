@@ -0,0 +1,182 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "strings"
+
+// WithDefaultFlags sets flags applied to every verb of the Client which accepts them, such as Quiet and AttachNoVerify in CI.
+//
+// A flag is applied to the verbs whose flag interface it implements, such as AttachFlag, and which accept its hdiutil option.
+// A default flag is skipped when the call gives the same option, its negation or an option excluding it: AttachVerify given
+// to a call overrides a default AttachNoVerify, AttachMountPoint a default AttachNoMount, and any of Quiet, Verbose and Debug
+// overrides another one.
+// Plist is ignored, as each verb chooses the output format it parses, and so are Quiet, Verbose and Debug for the verbs
+// run with -plist, as Quiet would leave no property list to parse.
+func WithDefaultFlags(flags ...interface{}) ClientOption {
+	return func(c *Client) {
+		c.defaultFlags = append(c.defaultFlags, flags...)
+	}
+}
+
+// verbosityFlags is the options setting the amount of output, which override each other.
+var verbosityFlags = map[string]bool{
+	"quiet":   true,
+	"verbose": true,
+	"debug":   true,
+}
+
+// exclusiveOptions is the options which exclude each other under different names, by the option standing for all of them.
+var exclusiveOptions = map[string]string{
+	"mountpoint":  "mount", // also -mount and -nomount
+	"mountroot":   "mount",
+	"mountrandom": "mount",
+	"readwrite":   "readonly",
+	"sectors":     "size",
+	"megabytes":   "size",
+}
+
+// applyDefaultFlags inserts the default flags of c accepted by the verb of cmd and not overridden by its arguments before them.
+// They are inserted once, even if cmd is run again, such as to retry it.
+func (c *Client) applyDefaultFlags(cmd *command) {
+	if len(c.defaultFlags) == 0 || cmd.defaulted {
+		return
+	}
+	cmd.defaulted = true
+
+	given := make(map[string]bool)
+	for _, arg := range cmd.args {
+		if name, ok := optionName(cmd.verb, arg); ok {
+			given[name] = true
+		}
+	}
+
+	var defaults []string
+	for _, flag := range c.defaultFlags {
+		args, ok := verbFlagArgs(cmd.verb, flag)
+		if !ok || len(args) == 0 {
+			continue
+		}
+		name, ok := optionName(cmd.verb, args[0])
+		if !ok || name == "plist" || given[name] {
+			continue
+		}
+		if name == "verbosity" && (cmd.plist || cmd.hasArg("-plist")) {
+			continue
+		}
+		given[name] = true
+		defaults = append(defaults, args...)
+		if p, ok := flag.(Passphrase); ok {
+			cmd.stdin = p.stdin()
+		}
+	}
+	cmd.args = append(defaults, cmd.args...)
+}
+
+// optionName returns the option of the argument arg of verb, without the leading dash nor the "no" of a negation,
+// "verbosity" for the options of verbosityFlags, or the option standing for the exclusiveOptions.
+// It reports false if arg is not an option accepted by verb.
+func optionName(verb, arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", false
+	}
+	name := strings.TrimPrefix(arg, "-")
+	if verbosityFlags[name] {
+		return "verbosity", true
+	}
+	accepted := verbFlags[verb]
+	base := strings.TrimPrefix(name, "no")
+	ok := accepted[name] || accepted[base] || accepted["no"+base]
+	if excl, found := exclusiveOptions[base]; found {
+		base = excl
+	}
+	return base, ok
+}
+
+// verbFlagArgs returns the arguments of flag for verb, and whether flag is a flag of verb.
+func verbFlagArgs(verb string, flag interface{}) ([]string, bool) {
+	switch verb {
+	case "attach":
+		if f, ok := flag.(AttachFlag); ok {
+			return f.AttachFlag(), true
+		}
+	case "burn":
+		if f, ok := flag.(BurnFlag); ok {
+			return f.BurnFlag(), true
+		}
+	case "checksum":
+		if f, ok := flag.(ChecksumFlag); ok {
+			return f.ChecksumFlag(), true
+		}
+	case "convert":
+		if f, ok := flag.(ConvertFlag); ok {
+			return f.ConvertFlag(), true
+		}
+	case "create":
+		if f, ok := flag.(CreateFlag); ok {
+			return f.CreateFlag(), true
+		}
+	case "detach":
+		if f, ok := flag.(DetachFlag); ok {
+			return f.DetachFlag(), true
+		}
+	case "eject":
+		if f, ok := flag.(EjectFlag); ok {
+			return f.EjectFlag(), true
+		}
+	case "fsid":
+		if f, ok := flag.(FsidFlag); ok {
+			return f.FsidFlag(), true
+		}
+	case "imageinfo":
+		if f, ok := flag.(ImageinfoFlag); ok {
+			return f.ImageinfoFlag(), true
+		}
+	case "info":
+		if f, ok := flag.(InfoFlag); ok {
+			return f.InfoFlag(), true
+		}
+	case "isencrypted":
+		if f, ok := flag.(IsencryptedFlag); ok {
+			return f.IsencryptedFlag(), true
+		}
+	case "makehybrid":
+		if f, ok := flag.(MakehybridFlag); ok {
+			return f.MakehybridFlag(), true
+		}
+	case "plugins":
+		if f, ok := flag.(PluginsFlag); ok {
+			return f.PluginsFlag(), true
+		}
+	case "pmap":
+		if f, ok := flag.(PmapFlag); ok {
+			return f.PmapFlag(), true
+		}
+	case "resize":
+		if f, ok := flag.(ResizeFlag); ok {
+			return f.ResizeFlag(), true
+		}
+	case "segment":
+		if f, ok := flag.(SegmentFlag); ok {
+			return f.SegmentFlag(), true
+		}
+	case "udifderez":
+		if f, ok := flag.(UdifderezFlag); ok {
+			return f.UdifderezFlag(), true
+		}
+	case "udifrez":
+		if f, ok := flag.(UdifrezFlag); ok {
+			return f.UdifrezFlag(), true
+		}
+	case "unmount":
+		if f, ok := flag.(UnmountFlag); ok {
+			return f.UnmountFlag(), true
+		}
+	case "verify":
+		if f, ok := flag.(VerifyFlag); ok {
+			return f.VerifyFlag(), true
+		}
+	}
+	return nil, false
+}
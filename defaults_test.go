@@ -0,0 +1,41 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyDefaultFlags(t *testing.T) {
+	c := NewClient(WithDefaultFlags(Quiet, AttachNoMount, AttachReadonly, AttachNoVerify, DetachForce))
+	tests := []struct {
+		cmd  *command
+		want []string
+	}{
+		{
+			&command{verb: "detach", args: []string{"/dev/disk4"}},
+			[]string{"-quiet", "-force", "/dev/disk4"},
+		},
+		{
+			&command{verb: "attach", args: []string{"-plist", "image.dmg"}, plist: true},
+			[]string{"-nomount", "-readonly", "-noverify", "-plist", "image.dmg"},
+		},
+		{
+			&command{verb: "attach", args: []string{"-plist", "image.dmg", "-mountpoint", "/tmp/mnt", "-readwrite", "-verify"}, plist: true},
+			[]string{"-plist", "image.dmg", "-mountpoint", "/tmp/mnt", "-readwrite", "-verify"},
+		},
+		{
+			&command{verb: "attach", args: []string{"image.dmg", "-mountrandom", "/tmp", "-debug"}},
+			[]string{"-readonly", "-noverify", "image.dmg", "-mountrandom", "/tmp", "-debug"},
+		},
+	}
+	for _, tt := range tests {
+		c.applyDefaultFlags(tt.cmd)
+		if !reflect.DeepEqual(tt.cmd.args, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.cmd.verb, tt.cmd.args, tt.want)
+		}
+	}
+}
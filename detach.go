@@ -4,35 +4,298 @@
 
 package hdiutil
 
-import "os/exec"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrResourceBusy is the error of Detach when the device is in use, such as while Spotlight or fseventsd
+// still hold the volume right after files were written to it.
+var ErrResourceBusy = errors.New("resource busy")
+
+// DetachFlag is a hdiutil detach command flag, returning its command-line arguments.
+type DetachFlag interface {
+	DetachFlag() []string
+}
 
-// detachFlag implements a hdiutil detach command flag interface.
-type detachFlag interface {
-	detachFlag() []string
+// DetachRetry retry the detach when it fails because the device is busy, before failing with ErrResourceBusy.
+//
+// The detach is tried at most Attempts times. The delay before each retry starts at Backoff and doubles, with a random jitter,
+// like AttachRetry. DetachWithRetry retries until a deadline instead.
+type DetachRetry struct {
+	Attempts int
+	Backoff  time.Duration
 }
 
-type detachForce bool
+func (d DetachRetry) DetachFlag() []string { return nil }
+
+type detachContinueOnError bool
+
+func (d detachContinueOnError) DetachFlag() []string { return nil }
 
-func (d detachForce) detachFlag() []string { return boolFlag("force", bool(d)) }
+type detachRetryForce bool
+
+func (d detachRetryForce) DetachFlag() []string { return nil }
 
 const (
-	// DetachForce ignore open files on mounted volumes, etc.
-	DetachForce detachForce = true
+	// DetachContinueOnError makes DetachMany attempt to detach every device even if some fail. It is ignored by Detach.
+	DetachContinueOnError detachContinueOnError = true
+
+	// DetachRetryForce makes DetachWithRetry force its last attempt with DetachForce. It is ignored by Detach.
+	DetachRetryForce detachRetryForce = true
 )
 
 // Detach detach a disk image and terminate any associated process.
-func Detach(deviceNode string, flags ...detachFlag) error {
-	cmd := exec.Command(hdiutilPath, "detach", deviceNode)
-	if len(flags) > 0 {
-		for _, flag := range flags {
-			cmd.Args = append(cmd.Args, flag.detachFlag()...)
+//
+// deviceNode may be any target accepted by ResolveTarget, such as a mount point or the image path.
+// The mount points and image paths are given to hdiutil as is in dry-run mode, as resolving them runs hdiutil info.
+// A busy device fails with ErrResourceBusy, after the retries of DetachRetry if given.
+func Detach(deviceNode string, flags ...DetachFlag) error {
+	return DefaultClient.Detach(deviceNode, flags...)
+}
+
+// Detach is like the package-level Detach, but runs hdiutil with the configuration of c.
+func (c *Client) Detach(deviceNode string, flags ...DetachFlag) error {
+	deviceNode, err := c.detachTarget(deviceNode)
+	if err != nil {
+		return err
+	}
+	return c.detach(deviceNode, flags)
+}
+
+// detach runs hdiutil detach on deviceNode, already resolved by detachTarget, retrying according to the DetachRetry among flags.
+func (c *Client) detach(deviceNode string, flags []DetachFlag) error {
+	cmd := c.command("detach", deviceNode)
+	cmd.target = deviceNode
+	var retry DetachRetry
+	for _, flag := range flags {
+		if r, ok := flag.(DetachRetry); ok {
+			retry = r
 		}
+		cmd.flag(flag, flag.DetachFlag())
 	}
 
-	err := cmd.Run()
+	for attempt := 1; ; attempt++ {
+		_, _, err := c.run(cmd)
+		if err == nil {
+			break
+		}
+		if !isDetachBusy(err) {
+			return err
+		}
+		if attempt >= retry.Attempts {
+			return fmt.Errorf("%w: %w", ErrResourceBusy, err)
+		}
+		time.Sleep(AttachRetry(retry).delay(attempt))
+	}
+	forgetAttach(deviceNode)
+
+	return nil
+}
+
+// defaultDetachBackoff is the first delay between the attempts of DetachWithRetry if none is given.
+const defaultDetachBackoff = 100 * time.Millisecond
+
+// DetachWithRetry detach deviceNode like Detach, retrying while it fails because the device is busy,
+// such as while Spotlight or an application still has files open on the volume.
+//
+// The delay before each retry starts at backoff, 100ms if it is zero, and doubles until ctx is done.
+// If ctx has a deadline, the last attempt is the one after which the next delay would pass it,
+// and it is made with DetachForce if DetachRetryForce is given. Other failures are returned without retrying.
+func DetachWithRetry(ctx context.Context, deviceNode string, backoff time.Duration, flags ...DetachFlag) error {
+	return DefaultClient.DetachWithRetry(ctx, deviceNode, backoff, flags...)
+}
+
+// DetachWithRetry is like the package-level DetachWithRetry, but runs hdiutil with the configuration of c.
+func (c *Client) DetachWithRetry(ctx context.Context, deviceNode string, backoff time.Duration, flags ...DetachFlag) error {
+	force := false
+	for _, flag := range flags {
+		if flag == DetachRetryForce {
+			force = true
+		}
+	}
+	flags = append(flags[:len(flags):len(flags)], WithContext(ctx))
+
+	// the target is resolved once, rather than by every attempt.
+	deviceNode, err := c.detachTarget(deviceNode)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	delay := backoff
+	if delay <= 0 {
+		delay = defaultDetachBackoff
+	}
+	for {
+		err := c.detach(deviceNode, flags)
+		if err == nil || !isDetachBusy(err) {
+			return err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			if force {
+				return c.detach(deviceNode, append(flags, DetachForce))
+			}
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
+
+// isDetachBusy reports whether the detach error err is caused by the device being in use.
+func isDetachBusy(err error) bool {
+	if errors.Is(err, ErrResourceBusy) {
+		return true
+	}
+	var codeErr *CodeError
+	if errors.As(err, &codeErr) {
+		switch codeErr.Code {
+		case IOReturnBusy, IOReturnStillOpen, IOReturnExclusiveAccess, OSErrFileBusy, Code(syscall.EBUSY):
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "resource busy")
+}
+
+// DetachStale detach the images attached longer than olderThan ago, forcing the detach of busy ones.
+//
+// If pathPrefix is not empty, only the images whose path is under pathPrefix, such as a CI workspace, are detached.
+// The returns detached device nodes and the joined errors of the images which could not be detached.
+//
+// The images may belong to other processes or users, so the forced detaches are audited and confirmed, see WithAuditWriter and WithConfirm.
+func DetachStale(olderThan time.Duration, pathPrefix string) ([]string, error) {
+	return DefaultClient.DetachStale(olderThan, pathPrefix)
+}
+
+// DetachStale is like the package-level DetachStale, but runs hdiutil with the configuration of c.
+func (c *Client) DetachStale(olderThan time.Duration, pathPrefix string) ([]string, error) {
+	info, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	preds := []InfoPredicate{OlderThan(olderThan)}
+	if pathPrefix != "" {
+		prefix := filepath.Clean(pathPrefix)
+		preds = append(preds, func(img InfoImage) bool {
+			path := filepath.Clean(img.ImagePath)
+			return path == prefix || strings.HasPrefix(path, prefix+string(filepath.Separator))
+		})
+	}
+
+	var (
+		detached []string
+		errs     []error
+	)
+	for _, img := range info.Filter(InfoQuery{}, preds...) {
+		deviceNode := img.DeviceNode().String()
+		if deviceNode == "" {
+			continue
+		}
+		if err := c.Detach(deviceNode); err != nil {
+			if err := c.Detach(deviceNode, DetachForce); err != nil {
+				errs = append(errs, fmt.Errorf("detach %s (%s): %w", deviceNode, img.ImagePath, err))
+				continue
+			}
+		}
+		detached = append(detached, deviceNode)
+	}
+
+	return detached, errors.Join(errs...)
+}
+
+// DetachMany detach the devices in order.
+//
+// DetachMany stops at the first device failing to detach, unless DetachContinueOnError is given,
+// in which case every device is attempted and the errors of all the failed ones are joined.
+func DetachMany(devices []DeviceNode, flags ...DetachFlag) error {
+	return DefaultClient.DetachMany(devices, flags...)
+}
+
+// DetachMany is like the package-level DetachMany, but runs hdiutil with the configuration of c.
+func (c *Client) DetachMany(devices []DeviceNode, flags ...DetachFlag) error {
+	continueOnError := false
+	for _, flag := range flags {
+		if flag == DetachContinueOnError {
+			continueOnError = true
+		}
+	}
+
+	var errs []error
+	for _, dev := range devices {
+		if err := c.Detach(dev.String(), flags...); err != nil {
+			errs = append(errs, fmt.Errorf("detach %s: %w", dev, err))
+			if !continueOnError {
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ensureDetachAttempts is the number of detach attempts of EnsureDetached, the last one forced.
+const ensureDetachAttempts = 4
+
+// EnsureDetached detach the image or device target, accepted in any form of ResolveTarget, unless it is already detached.
+//
+// Busy volumes are retried a few times with a growing delay before the detach is forced.
+// It is meant to be deferred in tests and provisioning scripts, as it succeeds if there is nothing to detach.
+func EnsureDetached(imageOrDevice string) error {
+	return DefaultClient.EnsureDetached(imageOrDevice)
+}
+
+// EnsureDetached is like the package-level EnsureDetached, but runs hdiutil with the configuration of c.
+func (c *Client) EnsureDetached(imageOrDevice string) error {
+	dev, err := c.ResolveTarget(imageOrDevice)
+	if errors.Is(err, ErrNotAttached) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	delay := 250 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		attached, err := c.isAttached(dev)
+		if err != nil {
+			return err
+		}
+		if !attached {
+			return nil
+		}
+
+		var flags []DetachFlag
+		if attempt == ensureDetachAttempts {
+			flags = append(flags, DetachForce)
+		}
+		err = c.Detach(dev.String(), flags...)
+		if err == nil || attempt == ensureDetachAttempts {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isAttached reports whether the device dev belongs to an attached image.
+func (c *Client) isAttached(dev DeviceNode) (bool, error) {
+	info, err := c.Info()
+	if err != nil {
+		return false, err
+	}
+	return len(info.Filter(InfoQuery{Device: dev.String()})) > 0, nil
 }
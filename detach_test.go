@@ -0,0 +1,93 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetachDryRun(t *testing.T) {
+	c := NewClient(WithDryRun(), WithHdiutilPath("/usr/bin/hdiutil"))
+	for target, want := range map[string]string{
+		"/Volumes/My Volume": "/usr/bin/hdiutil detach '/Volumes/My Volume'",
+		"rdisk4s1":           "/usr/bin/hdiutil detach /dev/disk4s1",
+	} {
+		var dry *DryRunError
+		if err := c.Detach(target); !errors.As(err, &dry) || dry.CommandLine() != want {
+			t.Errorf("Detach(%q) = %v, want %s", target, err, want)
+		}
+	}
+}
+
+func TestDetachWithRetryResolvesOnce(t *testing.T) {
+	info := readTestdata(t, "info.plist")
+	var calls []string
+	c := NewClient(WithRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		switch {
+		case args[0] == "info":
+			return info, nil, nil
+		case len(calls) < 4:
+			return nil, []byte("hdiutil: couldn't unmount \"disk4\" - Resource busy\n"), replayExitError(16)
+		}
+		return nil, nil, nil
+	})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.DetachWithRetry(ctx, "/Volumes/ My Volume ", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"info -plist", "detach /dev/disk4s1", "detach /dev/disk4s1", "detach /dev/disk4s1"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("invocations %q, want %q", calls, want)
+	}
+}
+
+func TestDetachStaleConfirmsForce(t *testing.T) {
+	attachments.Lock()
+	attachments.m["/dev/disk4"] = time.Now().Add(-time.Hour)
+	attachments.Unlock()
+	t.Cleanup(func() {
+		attachments.Lock()
+		delete(attachments.m, "/dev/disk4")
+		attachments.Unlock()
+	})
+
+	info := readTestdata(t, "info.plist")
+	var audit bytes.Buffer
+	var confirmed []string
+	c := NewClient(WithAuditWriter(&audit), WithConfirm(func(op Operation) error {
+		confirmed = append(confirmed, op.Verb+" "+strings.Join(op.Args, " "))
+		return nil
+	}), WithRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		switch {
+		case args[0] == "info":
+			return info, nil, nil
+		case args[len(args)-1] != "-force":
+			return nil, []byte("hdiutil: couldn't unmount \"disk4\" - Resource busy\n"), replayExitError(16)
+		}
+		return nil, nil, nil
+	})))
+
+	detached, err := c.DetachStale(time.Minute, "")
+	if err != nil || !reflect.DeepEqual(detached, []string{"/dev/disk4"}) {
+		t.Fatalf("DetachStale = %q, %v, want /dev/disk4", detached, err)
+	}
+	if want := []string{"detach /dev/disk4 -force"}; !reflect.DeepEqual(confirmed, want) {
+		t.Errorf("confirmed %q, want %q", confirmed, want)
+	}
+	if !strings.Contains(audit.String(), `"-force"`) {
+		t.Errorf("forced detach not audited: %s", audit.String())
+	}
+}
@@ -50,4 +50,16 @@
 //       On the other hand, hdiutil create -srcfolder creates a disk image
 //       container, puts a filesystem in it, and then copies the specified
 //       files to the new filesystem.
+//
+// Each verb takes the flags implementing its flag interface, such as AttachFlag for Attach.
+// The flag interfaces are exported so that other packages can provide custom flags:
+// the interface method returns the command-line arguments the flag adds to the hdiutil invocation.
+//
+// The verbs which hdiutil can report as a property list, such as Attach, Convert, Create, Info, ImageInfo, IsEncrypted and Plugins,
+// run with -plist and return the decoded result types instead of the text output, so passing Plist to them has no effect.
+// The output captured elsewhere is decoded with the Parse functions, such as ParseAttachPlist and ParseImageInfoPlist.
+//
+// The errors wrap their cause with the verb and the image or device it failed on, so that errors.Is and errors.As
+// see through them: a failed invocation is an *Error, which wraps the *exec.ExitError and, when hdiutil reported one,
+// a *CodeError, and errors.Is(err, exec.ErrNotFound) or errors.Is(err, fs.ErrPermission) hold for the failures to run it.
 package hdiutil // import "go-darwin.dev/hdiutil"
@@ -0,0 +1,74 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrDryRun is the error of the invocations of a Client in dry-run mode, see WithDryRun.
+var ErrDryRun = errors.New("dry run")
+
+// DryRunError is the error of an invocation not run because the Client is in dry-run mode. It wraps ErrDryRun.
+type DryRunError struct {
+	// Args is the command line which would have been run, starting with the path of the hdiutil binary,
+	// or of the wrapping sudo or taskpolicy binary with WithSudo and WithPriority.
+	Args []string
+}
+
+func (e *DryRunError) Error() string { return "dry run: " + e.CommandLine() }
+
+func (e *DryRunError) Unwrap() error { return ErrDryRun }
+
+// CommandLine returns the command line which would have been run, quoted to be pasted in a shell.
+func (e *DryRunError) CommandLine() string {
+	quoted := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// WithDryRun makes the Client build the hdiutil command lines without running them.
+//
+// Every verb then fails with a *DryRunError holding the command line it would have run, after the policy and read-only
+// checks but before any confirmation, so that flag combinations can be logged, audited or run by hand:
+//
+//	_, err := c.Convert(image, hdiutil.ConvertUDZO, out)
+//	var dry *hdiutil.DryRunError
+//	if errors.As(err, &dry) {
+//		fmt.Println(dry.CommandLine())
+//	}
+//
+// The verbs running several invocations, such as Compare, stop at the first one.
+func WithDryRun() ClientOption {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// dryRunError returns the *DryRunError of the invocation of args, the verb followed by its arguments.
+func (c *Client) dryRunError(args []string) error {
+	path := c.hdiutilPath
+	if path == "" {
+		var err error
+		if path, err = HdiutilPath(); err != nil {
+			path = "hdiutil"
+		}
+	}
+	if c.runner == nil {
+		path, args = ExecRunner{Sudo: c.sudo, Priority: c.priority}.wrap(path, args)
+	}
+	return &DryRunError{Args: append([]string{path}, args...)}
+}
+
+// shellQuote quotes s for a POSIX shell, unless it only has characters which need no quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=+,@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,39 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// EjectFlag is a hdiutil eject command flag, returning its command-line arguments.
+type EjectFlag interface {
+	EjectFlag() []string
+}
+
+// Eject eject a disk image, running hdiutil eject rather than detach.
+//
+// hdiutil treats eject as a synonym of detach, but scripts and audit logs may tell them apart.
+// deviceNode may be any target accepted by ResolveTarget, given to hdiutil as is in dry-run mode like with Detach.
+func Eject(deviceNode string, flags ...EjectFlag) error {
+	return DefaultClient.Eject(deviceNode, flags...)
+}
+
+// Eject is like the package-level Eject, but runs hdiutil with the configuration of c.
+func (c *Client) Eject(deviceNode string, flags ...EjectFlag) error {
+	deviceNode, err := c.detachTarget(deviceNode)
+	if err != nil {
+		return err
+	}
+
+	cmd := c.command("eject", deviceNode)
+	cmd.target = deviceNode
+	for _, flag := range flags {
+		cmd.flag(flag, flag.EjectFlag())
+	}
+
+	if _, _, err := c.run(cmd); err != nil {
+		return err
+	}
+	forgetAttach(deviceNode)
+
+	return nil
+}
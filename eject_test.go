@@ -0,0 +1,19 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEjectDryRun(t *testing.T) {
+	c := NewClient(WithDryRun(), WithHdiutilPath("/usr/bin/hdiutil"))
+	var dry *DryRunError
+	err := c.Eject("/tmp/image.dmg")
+	if !errors.As(err, &dry) || dry.CommandLine() != "/usr/bin/hdiutil eject /tmp/image.dmg" {
+		t.Errorf("Eject = %v, want the eject command line", err)
+	}
+}
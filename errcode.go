@@ -0,0 +1,160 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"regexp"
+	"strconv"
+	"syscall"
+)
+
+// Code is an error number reported by hdiutil, such as "error -536870208".
+//
+// hdiutil reports the errors of the DiskImages framework as IOKit return codes, Carbon OSStatus codes or errno values.
+type Code int32
+
+// ioReturnBase is the base of the IOKit common return codes, sys_iokit|sub_iokit_common.
+const ioReturnBase Code = -0x20000000
+
+// IOKit return codes, see IOKit/IOReturn.h.
+const (
+	IOReturnError            = ioReturnBase + 0x2bc
+	IOReturnNoMemory         = ioReturnBase + 0x2bd
+	IOReturnNoResources      = ioReturnBase + 0x2be
+	IOReturnIPCError         = ioReturnBase + 0x2bf
+	IOReturnNoDevice         = ioReturnBase + 0x2c0
+	IOReturnNotPrivileged    = ioReturnBase + 0x2c1
+	IOReturnBadArgument      = ioReturnBase + 0x2c2
+	IOReturnExclusiveAccess  = ioReturnBase + 0x2c5
+	IOReturnUnsupported      = ioReturnBase + 0x2c7
+	IOReturnInternalError    = ioReturnBase + 0x2c9
+	IOReturnIOError          = ioReturnBase + 0x2ca
+	IOReturnNotReadable      = ioReturnBase + 0x2ce
+	IOReturnNotWritable      = ioReturnBase + 0x2cf
+	IOReturnBadMedia         = ioReturnBase + 0x2d1
+	IOReturnStillOpen        = ioReturnBase + 0x2d2
+	IOReturnBusy             = ioReturnBase + 0x2d5
+	IOReturnTimeout          = ioReturnBase + 0x2d6
+	IOReturnOffline          = ioReturnBase + 0x2d7
+	IOReturnNotReady         = ioReturnBase + 0x2d8
+	IOReturnNoSpace          = ioReturnBase + 0x2db
+	IOReturnNotPermitted     = ioReturnBase + 0x2e2
+	IOReturnNoMedia          = ioReturnBase + 0x2e4
+	IOReturnUnformattedMedia = ioReturnBase + 0x2e5
+	IOReturnAborted          = ioReturnBase + 0x2eb
+	IOReturnNotFound         = ioReturnBase + 0x2f0
+)
+
+// Carbon OSStatus codes, see CarbonCore/MacErrors.h.
+const (
+	OSErrDiskFull       Code = -34
+	OSErrIO             Code = -36
+	OSErrFileNotFound   Code = -43
+	OSErrFileBusy       Code = -47
+	OSErrDuplicateName  Code = -48
+	OSErrPermission     Code = -54
+	OSErrWritePerm      Code = -61
+	OSErrDirNotFound    Code = -120
+	OSErrUserCanceled   Code = -128
+	OSErrAuthentication Code = -5000
+)
+
+// codeNames is the names and descriptions of the known codes.
+var codeNames = map[Code]struct{ name, desc string }{
+	IOReturnError:            {"kIOReturnError", "general error"},
+	IOReturnNoMemory:         {"kIOReturnNoMemory", "can't allocate memory"},
+	IOReturnNoResources:      {"kIOReturnNoResources", "resource shortage"},
+	IOReturnIPCError:         {"kIOReturnIPCError", "error during IPC"},
+	IOReturnNoDevice:         {"kIOReturnNoDevice", "no such device"},
+	IOReturnNotPrivileged:    {"kIOReturnNotPrivileged", "privilege violation"},
+	IOReturnBadArgument:      {"kIOReturnBadArgument", "invalid argument"},
+	IOReturnExclusiveAccess:  {"kIOReturnExclusiveAccess", "exclusive access and device already open"},
+	IOReturnUnsupported:      {"kIOReturnUnsupported", "unsupported function"},
+	IOReturnInternalError:    {"kIOReturnInternalError", "internal error"},
+	IOReturnIOError:          {"kIOReturnIOError", "general I/O error"},
+	IOReturnNotReadable:      {"kIOReturnNotReadable", "read not supported"},
+	IOReturnNotWritable:      {"kIOReturnNotWritable", "write not supported"},
+	IOReturnBadMedia:         {"kIOReturnBadMedia", "media error"},
+	IOReturnStillOpen:        {"kIOReturnStillOpen", "device(s) still open"},
+	IOReturnBusy:             {"kIOReturnBusy", "device busy"},
+	IOReturnTimeout:          {"kIOReturnTimeout", "I/O timeout"},
+	IOReturnOffline:          {"kIOReturnOffline", "device offline"},
+	IOReturnNotReady:         {"kIOReturnNotReady", "not ready"},
+	IOReturnNoSpace:          {"kIOReturnNoSpace", "no space for data"},
+	IOReturnNotPermitted:     {"kIOReturnNotPermitted", "not permitted"},
+	IOReturnNoMedia:          {"kIOReturnNoMedia", "no media present"},
+	IOReturnUnformattedMedia: {"kIOReturnUnformattedMedia", "media not formatted"},
+	IOReturnAborted:          {"kIOReturnAborted", "operation aborted"},
+	IOReturnNotFound:         {"kIOReturnNotFound", "data was not found"},
+
+	OSErrDiskFull:       {"dskFulErr", "disk full"},
+	OSErrIO:             {"ioErr", "I/O error"},
+	OSErrFileNotFound:   {"fnfErr", "file not found"},
+	OSErrFileBusy:       {"fBsyErr", "file is busy"},
+	OSErrDuplicateName:  {"dupFNErr", "duplicate filename"},
+	OSErrPermission:     {"permErr", "permissions error"},
+	OSErrWritePerm:      {"wrPermErr", "write permissions error"},
+	OSErrDirNotFound:    {"dirNFErr", "directory not found"},
+	OSErrUserCanceled:   {"userCanceledErr", "user canceled"},
+	OSErrAuthentication: {"afpAccessDenied", "authentication failed or access denied"},
+}
+
+// Name returns the symbolic name of c, such as kIOReturnNoDevice, or empty if c is unknown.
+func (c Code) Name() string {
+	return codeNames[c].name
+}
+
+// Description returns the human-readable description of c, or empty if c is unknown.
+// The positive codes are described as errno values.
+func (c Code) Description() string {
+	if n, ok := codeNames[c]; ok {
+		return n.desc
+	}
+	if c > 0 {
+		return syscall.Errno(c).Error()
+	}
+	return ""
+}
+
+func (c Code) String() string {
+	switch name, desc := c.Name(), c.Description(); {
+	case name != "":
+		return name + " (" + strconv.Itoa(int(c)) + "): " + desc
+	case desc != "":
+		return "errno " + strconv.Itoa(int(c)) + ": " + desc
+	}
+	return "error " + strconv.Itoa(int(c))
+}
+
+// CodeError is an hdiutil failure reporting an error number.
+type CodeError struct {
+	Code Code
+	Err  error
+}
+
+func (e *CodeError) Error() string {
+	if e.Code.Description() != "" {
+		return e.Err.Error() + " (" + e.Code.String() + ")"
+	}
+	return e.Err.Error()
+}
+
+func (e *CodeError) Unwrap() error { return e.Err }
+
+// codeRe matches the error numbers in the hdiutil output, such as "error -536870208" or "(error code 16)".
+var codeRe = regexp.MustCompile(`\berror(?: code)? (-?\d+)\b`)
+
+// findCode returns the last error number reported in out.
+func findCode(out []byte) (Code, bool) {
+	all := codeRe.FindAllSubmatch(out, -1)
+	if len(all) == 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(string(all[len(all)-1][1]), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return Code(n), true
+}
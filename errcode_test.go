@@ -0,0 +1,29 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "testing"
+
+func TestFindCode(t *testing.T) {
+	tests := []struct {
+		out  string
+		want Code
+		ok   bool
+	}{
+		{"", 0, false},
+		{"hdiutil: attach failed - no mountable file systems\n", 0, false},
+		{"hdiutil: attach: WARNING: error -536870208\n", -536870208, true},
+		{"hdiutil: detach failed - Resource busy (error code 16)\n", 16, true},
+		{"error -5341\nhdiutil: create failed - error -5342\n", -5342, true},
+		{"terror 12\n", 0, false},
+		{"error 99999999999\n", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := findCode([]byte(tt.out))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("findCode(%q) = %d, %v, want %d, %v", tt.out, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Error is the error of a failed hdiutil invocation. The verbs return it, possibly wrapped, when hdiutil or one of the helper
+// tools they run, such as diskutil, fails, so that errors.As gives the details of the failure:
+//
+//	var herr *hdiutil.Error
+//	if errors.As(err, &herr) && herr.ExitStatus == 1 {
+//		log.Printf("hdiutil %s failed: %s", herr.Verb, herr.Stderr)
+//	}
+type Error struct {
+	// Verb is the hdiutil verb, such as "create", or the name of the helper tool, such as "diskutil".
+	Verb string
+
+	// Target is the image or device the invocation operates on, or empty for the verbs without one, such as info.
+	Target string
+
+	// Args is the arguments following the verb, with the secrets redacted as in the logs of WithLogger.
+	Args []string
+
+	// ExitStatus is the exit status of hdiutil, or -1 if it did not exit normally, such as when killed on timeout.
+	ExitStatus int
+
+	// Stderr is the standard error of hdiutil, or the last lines of the output of the verbs reporting Progress.
+	Stderr []byte
+
+	// StdoutTail is the last lines of the standard output of hdiutil if its standard error is empty,
+	// as some verbs, such as makehybrid or attach without -plist, report their failure there.
+	StdoutTail []byte
+
+	// Err is the underlying error, such as an *exec.ExitError, possibly wrapped in a *CodeError, ErrTimeout or ErrSudoAuth.
+	Err error
+
+	// tool reports whether Verb is a helper tool rather than an hdiutil verb.
+	tool bool
+}
+
+func (e *Error) Error() string {
+	msg := "hdiutil " + e.Verb
+	if e.tool {
+		msg = e.Verb
+	}
+	if e.Target != "" {
+		msg += " " + e.Target
+	}
+	msg += ": " + e.Err.Error()
+	if s := strings.TrimSpace(string(e.Stderr)); s != "" {
+		msg += ": " + s
+	} else if s := strings.TrimSpace(string(e.StdoutTail)); s != "" {
+		msg += ": " + s
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// newError returns the *Error of the failed invocation cmd.
+// The standard output of a -plist invocation is not kept, as it is a property list rather than diagnostics.
+func newError(cmd *command, stdout, stderr []byte, err error) *Error {
+	e := &Error{Verb: cmd.verb, Target: cmd.target, Args: redactArgs(cmd.args), ExitStatus: exitStatus(err), Stderr: stderr, Err: err, tool: cmd.tool}
+	if len(bytes.TrimSpace(stderr)) == 0 && !cmd.plist {
+		e.StdoutTail = lastLines(stdout, progressTail)
+	}
+	return e
+}
+
+// lastLines returns the last n non-empty lines of out.
+func lastLines(out []byte, n int) []byte {
+	var lines [][]byte
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrDigestMismatch is returned by FetchAndAttach when the downloaded image does not have the expected digest.
+var ErrDigestMismatch = errors.New("image digest mismatch")
+
+// fetchConfig is the configuration of FetchAndAttach, set by the FetchOption values among the attach flags.
+type fetchConfig struct {
+	dir             string
+	client          *http.Client
+	stripQuarantine bool
+}
+
+// FetchOption configures the download of FetchAndAttach instead of adding hdiutil arguments.
+type FetchOption struct {
+	apply func(*fetchConfig)
+}
+
+// AttachFlag implements AttachFlag with no arguments, so that o is accepted by FetchAndAttach.
+func (o FetchOption) AttachFlag() []string { return nil }
+
+// WithFetchDir sets the directory where FetchAndAttach stores the downloaded image. The default is the Client temporary directory.
+func WithFetchDir(dir string) FetchOption {
+	return FetchOption{apply: func(c *fetchConfig) { c.dir = dir }}
+}
+
+// WithHTTPClient sets the HTTP client used by FetchAndAttach. The default is http.DefaultClient.
+func WithHTTPClient(client *http.Client) FetchOption {
+	return FetchOption{apply: func(c *fetchConfig) { c.client = client }}
+}
+
+// WithStripQuarantine removes the com.apple.quarantine extended attribute of the downloaded image before attaching it,
+// so that its volumes are not subject to Gatekeeper translocation.
+// xattr is run with the configuration of the Client, like its other helper tools, see WithToolRunner.
+func WithStripQuarantine() FetchOption {
+	return FetchOption{apply: func(c *fetchConfig) { c.stripQuarantine = true }}
+}
+
+// FetchAndAttach downloads the image at url, verifies its SHA-256 digest against the hex-encoded expectedSHA256 and attaches it with flags.
+// It returns the device node path of the attached image.
+//
+// The image is downloaded next to a ".part" file, so an interrupted download is resumed with an HTTP range request by the next call.
+// An image already downloaded with the expected digest is not downloaded again.
+// The image is kept after attaching, as it backs the attached device.
+// ErrDigestMismatch is returned, and the download removed, if the digest does not match.
+func FetchAndAttach(ctx context.Context, url, expectedSHA256 string, flags ...AttachFlag) (string, error) {
+	return DefaultClient.FetchAndAttach(ctx, url, expectedSHA256, flags...)
+}
+
+// FetchAndAttach is like the package-level FetchAndAttach, but runs hdiutil with the configuration of c.
+func (c *Client) FetchAndAttach(ctx context.Context, url, expectedSHA256 string, flags ...AttachFlag) (string, error) {
+	cfg := fetchConfig{client: http.DefaultClient}
+	attachFlags := []AttachFlag{WithContext(ctx)}
+	for _, f := range flags {
+		if o, ok := f.(FetchOption); ok {
+			o.apply(&cfg)
+			continue
+		}
+		attachFlags = append(attachFlags, f)
+	}
+	if cfg.dir == "" {
+		cfg.dir = c.tempDir()
+	}
+
+	name, err := fetchName(url)
+	if err != nil {
+		return "", err
+	}
+	image := filepath.Join(cfg.dir, name)
+
+	if err := fetchImage(ctx, cfg.client, url, image, strings.ToLower(expectedSHA256)); err != nil {
+		return "", err
+	}
+
+	if cfg.stripQuarantine {
+		if err := c.stripQuarantine(ctx, image); err != nil {
+			return "", err
+		}
+	}
+
+	result, err := c.Attach(image, attachFlags...)
+	return result.DeviceNode().String(), err
+}
+
+// fetchName returns the file name of the image downloaded from rawurl.
+func fetchName(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "", fmt.Errorf("no image file name in %s", rawurl)
+	}
+	return name, nil
+}
+
+// fetchImage downloads rawurl to image, unless image already has the digest want.
+func fetchImage(ctx context.Context, client *http.Client, rawurl, image, want string) error {
+	if sum, err := fileSHA256(image); err == nil && sum == want {
+		return nil
+	}
+
+	part := image + ".part"
+	f, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	offset, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawurl, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK, http.StatusRequestedRangeNotSatisfiable:
+		// the server does not support ranges, or the partial file is stale: start over.
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		h.Reset()
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			req.Header.Del("Range")
+			if resp, err = client.Do(req); err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("fetch %s: %s", rawurl, resp.Status)
+			}
+		}
+	default:
+		return fmt.Errorf("fetch %s: %s", rawurl, resp.Status)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return fmt.Errorf("fetch %s: %w", rawurl, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != want {
+		os.Remove(part)
+		return fmt.Errorf("%s: %w: got sha256 %s, want %s", rawurl, ErrDigestMismatch, sum, want)
+	}
+
+	return os.Rename(part, image)
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stripQuarantine removes the com.apple.quarantine extended attribute of path, if any, running xattr with ctx.
+func (c *Client) stripQuarantine(ctx context.Context, path string) error {
+	cmd := c.toolCommand(xattrPath, "-d", "com.apple.quarantine", path)
+	cmd.target, cmd.modifies = path, true
+	WithContext(ctx).apply(&cmd.call)
+	_, stderr, err := c.runTool(cmd)
+	if err != nil && !bytes.Contains(stderr, []byte("No such xattr")) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStripQuarantine(t *testing.T) {
+	var ran []string
+	tool := func(stderr string, err error) ClientOption {
+		return WithToolRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+			ran = args
+			return nil, []byte(stderr), err
+		}))
+	}
+	ctx := context.Background()
+
+	if err := NewClient(tool("", nil)).stripQuarantine(ctx, "/tmp/image.dmg"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/usr/bin/xattr", "-d", "com.apple.quarantine", "/tmp/image.dmg"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+
+	noAttr := "xattr: /tmp/image.dmg: No such xattr: com.apple.quarantine\n"
+	if err := NewClient(tool(noAttr, replayExitError(1))).stripQuarantine(ctx, "/tmp/image.dmg"); err != nil {
+		t.Errorf("image without quarantine: %v", err)
+	}
+	if err := NewClient(tool("xattr: [Errno 1] Operation not permitted\n", replayExitError(1))).stripQuarantine(ctx, "/tmp/image.dmg"); err == nil {
+		t.Error("xattr failure ignored")
+	}
+
+	ran = nil
+	if err := NewClient(tool("", nil), WithReadOnly()).stripQuarantine(ctx, "/tmp/image.dmg"); !errors.Is(err, ErrReadOnly) || ran != nil {
+		t.Errorf("read-only: got %v, ran %q, want ErrReadOnly", err, ran)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// finalizeConfig is the configuration of Finalize, set by its FinalizeOption values.
+type finalizeConfig struct {
+	flatten      bool
+	identity     string
+	convertFlags []ConvertFlag
+}
+
+// FinalizeOption configures Finalize.
+type FinalizeOption struct {
+	apply func(*finalizeConfig)
+}
+
+// WithFlatten flattens the finalized image, embedding its resources in the data fork, if it is not already.
+func WithFlatten() FinalizeOption {
+	return FinalizeOption{apply: func(c *finalizeConfig) { c.flatten = true }}
+}
+
+// WithSigningIdentity signs the finalized image with codesign(1) using the signing identity, such as "Developer ID Application: ...".
+// codesign is run with the configuration of the Client, like its other helper tools, see WithToolRunner.
+func WithSigningIdentity(identity string) FinalizeOption {
+	return FinalizeOption{apply: func(c *finalizeConfig) { c.identity = identity }}
+}
+
+// WithConvertFlags adds flags to the conversion of Finalize.
+func WithConvertFlags(flags ...ConvertFlag) FinalizeOption {
+	return FinalizeOption{apply: func(c *finalizeConfig) { c.convertFlags = append(c.convertFlags, flags...) }}
+}
+
+// Finalize turns the working read/write image src into the release image out in format, UDZO if zero.
+//
+// src is detached if it is attached, shrunk to the minimum size of its filesystem, converted to out and the result is verified.
+// With the options, out is then flattened and signed. out is removed if any step after the conversion fails.
+// Shrinking modifies src, so Finalize should run on an image which is not needed at its original size anymore.
+func Finalize(src, out string, format Format, opts ...FinalizeOption) error {
+	return DefaultClient.Finalize(src, out, format, opts...)
+}
+
+// Finalize is like the package-level Finalize, but runs hdiutil with the configuration of c.
+func (c *Client) Finalize(src, out string, format Format, opts ...FinalizeOption) error {
+	var cfg finalizeConfig
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	if format == 0 {
+		format = ConvertUDZO
+	}
+
+	if err := c.EnsureDetached(src); err != nil {
+		return fmt.Errorf("detach %s: %w", src, err)
+	}
+	if err := c.Resize(src, ResizeMin); err != nil {
+		return fmt.Errorf("shrink %s: %w", src, err)
+	}
+	if _, err := c.Convert(src, format, out, cfg.convertFlags...); err != nil {
+		return fmt.Errorf("convert %s: %w", src, err)
+	}
+
+	if err := c.finalizeOutput(out, &cfg); err != nil {
+		os.Remove(out)
+		return err
+	}
+
+	return nil
+}
+
+// finalizeOutput verifies, flattens and signs the converted image out.
+func (c *Client) finalizeOutput(out string, cfg *finalizeConfig) error {
+	if _, err := c.Verify(out); err != nil {
+		return fmt.Errorf("verify %s: %w", out, err)
+	}
+
+	if cfg.flatten {
+		flat, err := IsFlattened(out)
+		if err != nil {
+			return err
+		}
+		if !flat {
+			if err := c.flatten(out); err != nil {
+				return fmt.Errorf("flatten %s: %w", out, err)
+			}
+		}
+	}
+
+	if cfg.identity != "" {
+		sign := c.toolCommand("codesign", "--sign", cfg.identity, "--timestamp", out)
+		sign.target, sign.output = out, out
+		if _, _, err := c.runTool(sign); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flatten embeds the resources of the UDIF image in its data fork.
+func (c *Client) flatten(image string) error {
+	cmd := c.command("flatten", image)
+	cmd.target = image
+	cmd.output = image
+
+	_, _, err := c.run(cmd)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
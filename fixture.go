@@ -0,0 +1,201 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNoFixture is returned by a Replayer when no recorded interaction matches an invocation.
+var ErrNoFixture = errors.New("no recorded hdiutil interaction")
+
+// Interaction is a recorded hdiutil invocation.
+type Interaction struct {
+	Args []string `json:"args"`
+
+	// StdinSHA256 is the hex-encoded SHA-256 digest of the standard input, if any.
+	// The input itself is not recorded, as it may be a passphrase.
+	StdinSHA256 string `json:"stdin_sha256,omitempty"`
+
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitStatus int    `json:"exit_status"`
+
+	// Error is the error of an invocation which did not exit, such as a missing binary.
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder is a Runner recording the interactions of another Runner into a fixture file, in the order of the invocations.
+//
+// It is meant to capture real hdiutil runs on macOS once, for a Replayer to serve them in tests on any OS.
+// The file is rewritten after each invocation.
+type Recorder struct {
+	// Runner is the recorded Runner. The default is ExecRunner.
+	Runner Runner
+
+	// File is the path of the fixture file.
+	File string
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Run implements Runner.
+func (r *Recorder) Run(ctx context.Context, args []string, stdin io.Reader) (stdout, stderr []byte, err error) {
+	runner := r.Runner
+	if runner == nil {
+		runner = ExecRunner{}
+	}
+
+	var sum string
+	if stdin != nil {
+		in, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+		sum = stdinSHA256(in)
+		stdin = strings.NewReader(string(in))
+	}
+
+	stdout, stderr, err = runner.Run(ctx, args, stdin)
+
+	it := Interaction{
+		Args:        append([]string(nil), args...),
+		StdinSHA256: sum,
+		Stdout:      string(stdout),
+		Stderr:      string(stderr),
+	}
+	if err != nil {
+		var ec interface{ ExitCode() int }
+		if errors.As(err, &ec) && ec.ExitCode() >= 0 {
+			it.ExitStatus = ec.ExitCode()
+		} else {
+			it.Error = err.Error()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, it)
+	data, merr := json.MarshalIndent(r.interactions, "", "\t")
+	if merr != nil {
+		return stdout, stderr, merr
+	}
+	if werr := os.WriteFile(r.File, data, 0o644); werr != nil {
+		return stdout, stderr, werr
+	}
+
+	return stdout, stderr, err
+}
+
+// Replayer is a Runner serving the interactions recorded by a Recorder, without running hdiutil.
+//
+// Each invocation is served by the first interaction not served yet with the same arguments and standard input,
+// so a workflow repeating an invocation, such as info before and after attach, gets the recorded results in order.
+type Replayer struct {
+	// Match reports whether the recorded arguments match the invoked arguments. The default is an exact comparison.
+	// It allows ignoring the arguments which vary between runs, such as temporary paths.
+	Match func(recorded, args []string) bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	served       []bool
+}
+
+// NewReplayer returns a Replayer serving the interactions of the fixture file written by a Recorder.
+func NewReplayer(file string) (*Replayer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &Replayer{interactions: interactions, served: make([]bool, len(interactions))}, nil
+}
+
+// Run implements Runner.
+func (r *Replayer) Run(ctx context.Context, args []string, stdin io.Reader) (stdout, stderr []byte, err error) {
+	var sum string
+	if stdin != nil {
+		in, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, nil, err
+		}
+		sum = stdinSHA256(in)
+	}
+	match := r.Match
+	if match == nil {
+		match = equalArgs
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, it := range r.interactions {
+		if r.served[i] || it.StdinSHA256 != sum || !match(it.Args, args) {
+			continue
+		}
+		r.served[i] = true
+
+		switch {
+		case it.Error != "":
+			err = errors.New(it.Error)
+		case it.ExitStatus != 0:
+			err = replayExitError(it.ExitStatus)
+		}
+		return []byte(it.Stdout), []byte(it.Stderr), err
+	}
+
+	return nil, nil, fmt.Errorf("hdiutil %s: %w", strings.Join(args, " "), ErrNoFixture)
+}
+
+// Unserved returns the recorded interactions not served yet, to check a test ran the whole recorded workflow.
+func (r *Replayer) Unserved() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unserved []Interaction
+	for i, it := range r.interactions {
+		if !r.served[i] {
+			unserved = append(unserved, it)
+		}
+	}
+	return unserved
+}
+
+// replayExitError is the error of a replayed invocation which exited with a non-zero status.
+type replayExitError int
+
+func (e replayExitError) Error() string { return "exit status " + strconv.Itoa(int(e)) }
+
+// ExitCode returns the recorded exit status, like exec.ExitError.
+func (e replayExitError) ExitCode() int { return int(e) }
+
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func stdinSHA256(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestReplayWorkflow replays testdata/workflow.json, recorded from create, attach, info and a detach retried once on a busy volume.
+func TestReplayWorkflow(t *testing.T) {
+	r, err := NewReplayer(filepath.Join("testdata", "workflow.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithRunner(r))
+
+	const image = "/tmp/hdiutiltest/test.dmg"
+	path, err := c.Create(image, CreateSize("10m"), CreateHFSPlus, CreateVolname("Test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != image {
+		t.Errorf("created %s, want %s", path, image)
+	}
+
+	res, err := c.Attach(image, AttachNoBrowse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev := res.DeviceNode(); dev != "/dev/disk4" {
+		t.Errorf("attached device %s, want /dev/disk4", dev)
+	}
+	if mp := res.MountPoints(); len(mp) != 1 || mp[0] != "/Volumes/Test" {
+		t.Errorf("mount points %q, want [/Volumes/Test]", mp)
+	}
+
+	info, err := c.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Images) != 1 || info.Images[0].ImagePath != image || !info.Images[0].Writeable {
+		t.Errorf("info images %+v", info.Images)
+	}
+
+	if err := c.Detach("/dev/disk4", DetachRetry{Attempts: 2, Backoff: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	if unserved := r.Unserved(); len(unserved) != 0 {
+		t.Errorf("unserved interactions: %+v", unserved)
+	}
+}
+
+func TestReplayerNoFixture(t *testing.T) {
+	r, err := NewReplayer(filepath.Join("testdata", "workflow.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(WithRunner(r))
+
+	if _, err := c.ImageInfo("/tmp/hdiutiltest/other.dmg"); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("got %v, want ErrNoFixture", err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fixture.json")
+	rec := &Recorder{
+		File: file,
+		Runner: RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+			if args[0] == "detach" {
+				return nil, []byte("hdiutil: detach failed - No such file or directory\n"), replayExitError(1)
+			}
+			return []byte("\"disk4\" ejected.\n"), nil, nil
+		}),
+	}
+	c := NewClient(WithRunner(rec))
+	if err := c.Eject("/dev/disk4"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Detach("/dev/disk5"); err == nil {
+		t.Fatal("detach succeeded")
+	}
+
+	r, err := NewReplayer(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = NewClient(WithRunner(r))
+	if err := c.Eject("/dev/disk4"); err != nil {
+		t.Error(err)
+	}
+	var herr *Error
+	if err := c.Detach("/dev/disk5"); !errors.As(err, &herr) || herr.ExitStatus != 1 {
+		t.Errorf("replayed detach error %v, want exit status 1", err)
+	}
+}
@@ -4,7 +4,34 @@
 
 package hdiutil
 
-import "strconv"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFlag is wrapped by the errors of the invocations given a flag which their verb does not accept,
+// according to the verb tables of flags.yaml.
+var ErrUnsupportedFlag = errors.New("flag not accepted by the verb")
+
+// acceptsFlag reports whether the verb of cmd accepts the flag whose arguments are args.
+// The flags of the verbs without a table in flags.yaml, and those not starting with an option, are not checked.
+func (cmd *command) acceptsFlag(args []string) bool {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "-") || verbFlags[cmd.verb] == nil {
+		return true
+	}
+	_, ok := optionName(cmd.verb, args[0])
+	return ok
+}
+
+// validateFlags returns an error wrapping ErrUnsupportedFlag if cmd was given flags which its verb does not accept.
+func (cmd *command) validateFlags() error {
+	if len(cmd.unsupported) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s %s: %w", cmd.verb, strings.Join(cmd.unsupported, " "), ErrUnsupportedFlag)
+}
 
 func boolFlag(name string, b bool) []string {
 	if b {
@@ -24,12 +51,6 @@ func stringFlag(name, s string) []string {
 	return []string{"-" + name, s}
 }
 
-func stringSliceFlag(name string, s []string) []string {
-	a := []string{"-" + name}
-	a = append(a, s...)
-	return a
-}
-
 func intFlag(name string, i int) []string {
 	return []string{"-" + name, strconv.Itoa(i)}
 }
@@ -0,0 +1,60 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"testing"
+)
+
+// bogusFlag is a flag of an option which no verb accepts.
+type bogusFlag struct{}
+
+func (bogusFlag) AttachFlag() []string { return []string{"-bogus"} }
+func (bogusFlag) VerifyFlag() []string { return []string{"-bogus"} }
+
+func TestValidateFlags(t *testing.T) {
+	c := NewClient(WithDryRun())
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"attach", func() error {
+			_, err := c.Attach("image.dmg", AttachReadonly, AttachNoKernel, AttachMountRequired, AttachNoMount, AttachNoBrowse,
+				AttachOwnersOn, AttachIgnoreBadChecksums, AttachNoVerify, AttachMountPoint("/tmp/mnt"), Shadow("image.shadow"),
+				Srcimagekey{"k": "v"}, Passphrase("secret"), Cacert("ca.pem"), Quiet)
+			return err
+		}()},
+		{"create", func() error {
+			_, err := c.Create("image.dmg", CreateSize("10m"), CreateHFSPlus, CreateVolname("Test"), CreateLayout("GPTSPUD"),
+				CreateFSArgs{HFSBlockSize(4096), HFSJournalSize("16m")}, CreateAttach, Debug)
+			return err
+		}()},
+		{"convert", func() error {
+			_, err := c.Convert("image.dmg", ConvertUDZO, "out.dmg", ConvertPmap, Tgtimagekey{"zlib-level": "9"}, Verbose)
+			return err
+		}()},
+		{"makehybrid", func() error {
+			return c.Makehybrid("image.iso", "src", MakehybridISO, MakeHybridJoliet, MakehybridHFS, MakehybridNoEmulBoot,
+				MakehybridDefaultVolumeName, Srcimagekey{"k": "v"}, Stdinpass)
+		}()},
+		{"verify", func() error {
+			_, err := c.Verify("image.dmg", VerifyNoCache, Recover("recover.keychain"))
+			return err
+		}()},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrDryRun) {
+			t.Errorf("%s: got %v, want a dry run", tt.name, tt.err)
+		}
+	}
+
+	if _, err := c.Attach("image.dmg", bogusFlag{}); !errors.Is(err, ErrUnsupportedFlag) {
+		t.Errorf("attach -bogus: got %v, want ErrUnsupportedFlag", err)
+	}
+	if _, err := c.Verify("image.dmg", bogusFlag{}); !errors.Is(err, ErrUnsupportedFlag) {
+		t.Errorf("verify -bogus: got %v, want ErrUnsupportedFlag", err)
+	}
+}
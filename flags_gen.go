@@ -0,0 +1,520 @@
+// Code generated by flaggen from flags.yaml. DO NOT EDIT.
+
+package hdiutil
+
+type verifyCache bool
+
+// VerifyFlag returns the hdiutil verify -cache or -nocache argument of x.
+func (x verifyCache) VerifyFlag() []string { return boolNoFlag("cache", bool(x)) }
+
+const (
+	// VerifyCache do cache checksum-verification.
+	VerifyCache verifyCache = true
+
+	// VerifyNoCache do not cache checksum-verification cache.
+	VerifyNoCache verifyCache = false
+)
+
+type detachForce bool
+
+// DetachFlag returns the hdiutil detach -force argument of x.
+func (x detachForce) DetachFlag() []string { return boolFlag("force", bool(x)) }
+
+const (
+	// DetachForce ignore open files on mounted volumes, etc.
+	DetachForce detachForce = true
+)
+
+type ejectForce bool
+
+// EjectFlag returns the hdiutil eject -force argument of x.
+func (x ejectForce) EjectFlag() []string { return boolFlag("force", bool(x)) }
+
+const (
+	// EjectForce ignore open files on mounted volumes, as DetachForce.
+	EjectForce ejectForce = true
+)
+
+type unmountForce bool
+
+// UnmountFlag returns the hdiutil unmount -force argument of x.
+func (x unmountForce) UnmountFlag() []string { return boolFlag("force", bool(x)) }
+
+const (
+	// UnmountForce unmount the volume even if files on it are open.
+	UnmountForce unmountForce = true
+)
+
+type udifrezReplaceall bool
+
+// UdifrezFlag returns the hdiutil udifrez -replaceall argument of x.
+func (x udifrezReplaceall) UdifrezFlag() []string { return boolFlag("replaceall", bool(x)) }
+
+const (
+	// UdifrezReplaceAll delete all the existing resources of the image before embedding the new ones.
+	UdifrezReplaceAll udifrezReplaceall = true
+)
+
+type burnEject bool
+
+// BurnFlag returns the hdiutil burn -eject or -noeject argument of x.
+func (x burnEject) BurnFlag() []string { return boolNoFlag("eject", bool(x)) }
+
+const (
+	// BurnEject eject the disc after burning, the default.
+	BurnEject burnEject = true
+
+	// BurnNoEject do not eject the disc after burning.
+	BurnNoEject burnEject = false
+)
+
+type burnVerifyburn bool
+
+// BurnFlag returns the hdiutil burn -verifyburn or -noverifyburn argument of x.
+func (x burnVerifyburn) BurnFlag() []string { return boolNoFlag("verifyburn", bool(x)) }
+
+const (
+	// BurnVerifyBurn verify the disc contents after burning, the default.
+	BurnVerifyBurn burnVerifyburn = true
+
+	// BurnNoVerifyBurn do not verify the disc contents after burning.
+	BurnNoVerifyBurn burnVerifyburn = false
+)
+
+type burnErase bool
+
+// BurnFlag returns the hdiutil burn -erase argument of x.
+func (x burnErase) BurnFlag() []string { return boolFlag("erase", bool(x)) }
+
+const (
+	// BurnErase quickly erase the rewritable media before burning.
+	BurnErase burnErase = true
+)
+
+type burnFullerase bool
+
+// BurnFlag returns the hdiutil burn -fullerase argument of x.
+func (x burnFullerase) BurnFlag() []string { return boolFlag("fullerase", bool(x)) }
+
+const (
+	// BurnFullErase erase all sectors of the rewritable media before burning.
+	BurnFullErase burnFullerase = true
+)
+
+type burnTestburn bool
+
+// BurnFlag returns the hdiutil burn -testburn argument of x.
+func (x burnTestburn) BurnFlag() []string { return boolFlag("testburn", bool(x)) }
+
+const (
+	// BurnTestBurn do not turn on the laser, to test the burn without writing the media.
+	BurnTestBurn burnTestburn = true
+)
+
+// ChecksumType specify the type of checksum computed by checksum.
+type ChecksumType string
+
+const (
+	// ChecksumUDIFCRC32 is the CRC-32 image checksum, stored in UDIF images.
+	ChecksumUDIFCRC32 ChecksumType = "UDIF-CRC32"
+
+	// ChecksumUDIFMD5 is the MD5 image checksum of UDIF images.
+	ChecksumUDIFMD5 ChecksumType = "UDIF-MD5"
+
+	// ChecksumDC42 is the Disk Copy 4.2 checksum.
+	ChecksumDC42 ChecksumType = "DC42"
+
+	// ChecksumCRC28 is the CRC-32 of NDIF images.
+	ChecksumCRC28 ChecksumType = "CRC28"
+
+	// ChecksumCRC32 is the CRC-32 of the image data.
+	ChecksumCRC32 ChecksumType = "CRC32"
+
+	// ChecksumMD5 is the MD5 of the image data.
+	ChecksumMD5 ChecksumType = "MD5"
+
+	// ChecksumSHA is the SHA of the image data.
+	ChecksumSHA ChecksumType = "SHA"
+
+	// ChecksumSHA1 is the SHA-1 of the image data.
+	ChecksumSHA1 ChecksumType = "SHA1"
+
+	// ChecksumSHA256 is the SHA-256 of the image data.
+	ChecksumSHA256 ChecksumType = "SHA256"
+
+	// ChecksumSHA384 is the SHA-384 of the image data.
+	ChecksumSHA384 ChecksumType = "SHA384"
+
+	// ChecksumSHA512 is the SHA-512 of the image data.
+	ChecksumSHA512 ChecksumType = "SHA512"
+)
+
+// String returns x as given to hdiutil.
+func (x ChecksumType) String() string { return string(x) }
+
+// checksumTypes is the ChecksumType values known to hdiutil.
+var checksumTypes = map[ChecksumType]bool{
+	ChecksumUDIFCRC32: true,
+	ChecksumUDIFMD5:   true,
+	ChecksumDC42:      true,
+	ChecksumCRC28:     true,
+	ChecksumCRC32:     true,
+	ChecksumMD5:       true,
+	ChecksumSHA:       true,
+	ChecksumSHA1:      true,
+	ChecksumSHA256:    true,
+	ChecksumSHA384:    true,
+	ChecksumSHA512:    true,
+}
+
+// verbFlags is the command-line flags accepted by each hdiutil verb, without the leading dash.
+var verbFlags = map[string]map[string]bool{
+	"attach": {
+		"agentpass":            true,
+		"autofsck":             true,
+		"autoopen":             true,
+		"autoopenro":           true,
+		"autoopenrw":           true,
+		"cacert":               true,
+		"debug":                true,
+		"drivekey":             true,
+		"encryption":           true,
+		"idme":                 true,
+		"idmereveal":           true,
+		"idmetrash":            true,
+		"ignorebadchecksums":   true,
+		"imagekey":             true,
+		"insecurehttp":         true,
+		"kernel":               true,
+		"mount":                true,
+		"mountpoint":           true,
+		"mountrandom":          true,
+		"mountroot":            true,
+		"noautofsck":           true,
+		"noautoopen":           true,
+		"noautoopenro":         true,
+		"noautoopenrw":         true,
+		"nobrowse":             true,
+		"noidme":               true,
+		"noidmereveal":         true,
+		"noidmetrash":          true,
+		"noignorebadchecksums": true,
+		"nokernel":             true,
+		"nomount":              true,
+		"notremovable":         true,
+		"noverify":             true,
+		"owners":               true,
+		"plist":                true,
+		"puppetstrings":        true,
+		"quiet":                true,
+		"readonly":             true,
+		"readwrite":            true,
+		"recover":              true,
+		"section":              true,
+		"shadow":               true,
+		"srcimagekey":          true,
+		"stdinpass":            true,
+		"tgtimagekey":          true,
+		"verbose":              true,
+		"verify":               true,
+	},
+	"burn": {
+		"addpmap":         true,
+		"anydevice":       true,
+		"cacert":          true,
+		"debug":           true,
+		"device":          true,
+		"eject":           true,
+		"encryption":      true,
+		"erase":           true,
+		"forceclose":      true,
+		"fullerase":       true,
+		"insecurehttp":    true,
+		"list":            true,
+		"noaddpmap":       true,
+		"noeject":         true,
+		"noforceclose":    true,
+		"nooptimizeimage": true,
+		"noskipfinalfree": true,
+		"nounderrun":      true,
+		"noverifyburn":    true,
+		"optimizeimage":   true,
+		"puppetstrings":   true,
+		"quiet":           true,
+		"shadow":          true,
+		"sizequery":       true,
+		"skipfinalfree":   true,
+		"speed":           true,
+		"srcimagekey":     true,
+		"stdinpass":       true,
+		"testburn":        true,
+		"verbose":         true,
+		"verifyburn":      true,
+	},
+	"checksum": {
+		"cacert":        true,
+		"debug":         true,
+		"encryption":    true,
+		"insecurehttp":  true,
+		"plist":         true,
+		"puppetstrings": true,
+		"quiet":         true,
+		"recover":       true,
+		"shadow":        true,
+		"srcimagekey":   true,
+		"stdinpass":     true,
+		"type":          true,
+		"verbose":       true,
+	},
+	"convert": {
+		"agentpass":     true,
+		"align":         true,
+		"cacert":        true,
+		"certificate":   true,
+		"debug":         true,
+		"encryption":    true,
+		"format":        true,
+		"imagekey":      true,
+		"insecurehttp":  true,
+		"nopmap":        true,
+		"o":             true,
+		"plist":         true,
+		"pmap":          true,
+		"pubkey":        true,
+		"puppetstrings": true,
+		"quiet":         true,
+		"segmentSize":   true,
+		"shadow":        true,
+		"srcimagekey":   true,
+		"stdinpass":     true,
+		"tasks":         true,
+		"tgtimagekey":   true,
+		"verbose":       true,
+	},
+	"create": {
+		"agentpass":      true,
+		"align":          true,
+		"anyowners":      true,
+		"atomic":         true,
+		"attach":         true,
+		"autostretch":    true,
+		"certificate":    true,
+		"copyuid":        true,
+		"crossdev":       true,
+		"debug":          true,
+		"encryption":     true,
+		"format":         true,
+		"fs":             true,
+		"fsargs":         true,
+		"gid":            true,
+		"imagekey":       true,
+		"layout":         true,
+		"library":        true,
+		"megabytes":      true,
+		"mode":           true,
+		"noanyowners":    true,
+		"noatomic":       true,
+		"noautostretch":  true,
+		"nocrossdev":     true,
+		"noscrub":        true,
+		"ov":             true,
+		"partitionType":  true,
+		"plist":          true,
+		"pubkey":         true,
+		"puppetstrings":  true,
+		"quiet":          true,
+		"scrub":          true,
+		"sectors":        true,
+		"segmentSize":    true,
+		"size":           true,
+		"skipunreadable": true,
+		"srcdevice":      true,
+		"srcdir":         true,
+		"srcfolder":      true,
+		"srcimagekey":    true,
+		"stdinpass":      true,
+		"stretch":        true,
+		"tgtimagekey":    true,
+		"type":           true,
+		"uid":            true,
+		"verbose":        true,
+		"volname":        true,
+	},
+	"detach": {
+		"debug":   true,
+		"force":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"eject": {
+		"debug":   true,
+		"force":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"fsid": {
+		"debug":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"imageinfo": {
+		"cacert":       true,
+		"checksum":     true,
+		"debug":        true,
+		"encryption":   true,
+		"format":       true,
+		"insecurehttp": true,
+		"plist":        true,
+		"quiet":        true,
+		"recover":      true,
+		"shadow":       true,
+		"srcimagekey":  true,
+		"stdinpass":    true,
+		"verbose":      true,
+	},
+	"info": {
+		"debug":   true,
+		"plist":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"internet-enable": {
+		"debug":   true,
+		"no":      true,
+		"query":   true,
+		"quiet":   true,
+		"verbose": true,
+		"yes":     true,
+	},
+	"isencrypted": {
+		"debug":   true,
+		"plist":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"makehybrid": {
+		"abstract-file":          true,
+		"application":            true,
+		"bibliography-file":      true,
+		"boot-load-seg":          true,
+		"boot-load-size":         true,
+		"copyright-file":         true,
+		"debug":                  true,
+		"default-volume-name":    true,
+		"eltorito-boot":          true,
+		"eltorito-platform":      true,
+		"eltorito-specification": true,
+		"encryption":             true,
+		"hard-disk-boot":         true,
+		"hfs":                    true,
+		"hfs-blessed-directory":  true,
+		"hfs-openfolder":         true,
+		"hfs-startupfile-size":   true,
+		"hfs-volume-name":        true,
+		"hide-all":               true,
+		"hide-hfs":               true,
+		"hide-iso":               true,
+		"hide-joliet":            true,
+		"hide-udf":               true,
+		"iso":                    true,
+		"iso-volume-name":        true,
+		"joliet":                 true,
+		"joliet-volume-name":     true,
+		"keep-mac-specific":      true,
+		"no-boot":                true,
+		"no-emul-boot":           true,
+		"o":                      true,
+		"only-iso":               true,
+		"only-joliet":            true,
+		"only-udf":               true,
+		"plistin":                true,
+		"preparer":               true,
+		"print-size":             true,
+		"publisher":              true,
+		"puppetstrings":          true,
+		"quiet":                  true,
+		"shadow":                 true,
+		"srcimagekey":            true,
+		"stdinpass":              true,
+		"system-id":              true,
+		"udf":                    true,
+		"udf-version":            true,
+		"udf-volume-name":        true,
+		"verbose":                true,
+	},
+	"plugins": {
+		"debug":   true,
+		"plist":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"pmap": {
+		"debug":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"resize": {
+		"debug":           true,
+		"growonly":        true,
+		"imageonly":       true,
+		"limits":          true,
+		"nofinalgap":      true,
+		"partitionNumber": true,
+		"partitiononly":   true,
+		"quiet":           true,
+		"sectors":         true,
+		"shrinkonly":      true,
+		"size":            true,
+		"stdinpass":       true,
+		"verbose":         true,
+	},
+	"segment": {
+		"debug":            true,
+		"encryption":       true,
+		"firstSegmentSize": true,
+		"o":                true,
+		"puppetstrings":    true,
+		"quiet":            true,
+		"segmentCount":     true,
+		"segmentSize":      true,
+		"srcimagekey":      true,
+		"stdinpass":        true,
+		"tgtimagekey":      true,
+		"verbose":          true,
+	},
+	"udifderez": {
+		"debug":   true,
+		"quiet":   true,
+		"verbose": true,
+		"xml":     true,
+	},
+	"udifrez": {
+		"debug":      true,
+		"quiet":      true,
+		"replaceall": true,
+		"verbose":    true,
+		"xml":        true,
+	},
+	"unmount": {
+		"debug":   true,
+		"force":   true,
+		"quiet":   true,
+		"verbose": true,
+	},
+	"verify": {
+		"cacert":        true,
+		"cache":         true,
+		"debug":         true,
+		"encryption":    true,
+		"insecurehttp":  true,
+		"nocache":       true,
+		"plist":         true,
+		"puppetstrings": true,
+		"quiet":         true,
+		"recover":       true,
+		"shadow":        true,
+		"srcimagekey":   true,
+		"stdinpass":     true,
+		"verbose":       true,
+	},
+}
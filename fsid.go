@@ -0,0 +1,94 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// FilesystemProbe is the filesystem identification of a device entry, reported by hdiutil fsid.
+type FilesystemProbe struct {
+	// DevEntry is the probed device entry, such as /dev/disk4s1.
+	DevEntry string
+
+	// Properties is the "key: value" lines reported for DevEntry, such as the filesystem type and the volume name.
+	Properties map[string]string
+
+	// Lines is the other output lines reported for DevEntry.
+	Lines []string
+}
+
+// FsidFlag is a hdiutil fsid command flag, returning its command-line arguments.
+type FsidFlag interface {
+	FsidFlag() []string
+}
+
+// Fsid probe the filesystems of the device entry devEntry of an attached image, and of its partitions if devEntry is a whole disk,
+// to learn what an image contains before mounting it.
+//
+// fsid is not documented by hdiutil and its output varies between macOS releases,
+// so the results are only split per device entry and into the "key: value" properties.
+func Fsid(devEntry string, flags ...FsidFlag) ([]FilesystemProbe, error) {
+	return DefaultClient.Fsid(devEntry, flags...)
+}
+
+// Fsid is like the package-level Fsid, but runs hdiutil with the configuration of c.
+func (c *Client) Fsid(devEntry string, flags ...FsidFlag) ([]FilesystemProbe, error) {
+	cmd := c.command("fsid")
+	cmd.target = devEntry
+	for _, flag := range flags {
+		cmd.flag(flag, flag.FsidFlag())
+	}
+	cmd.args = append(cmd.args, devEntry)
+
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFsid(out, devEntry), nil
+}
+
+// parseFsid splits the hdiutil fsid output per device entry. A line starting with a device node path starts the results of that entry;
+// the lines preceding any of them are attributed to devEntry.
+func parseFsid(out []byte, devEntry string) []FilesystemProbe {
+	var probes []FilesystemProbe
+	cur := -1
+	start := func(dev string) {
+		probes = append(probes, FilesystemProbe{DevEntry: dev, Properties: make(map[string]string)})
+		cur = len(probes) - 1
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/dev/") {
+			dev, rest, _ := strings.Cut(line, " ")
+			start(strings.TrimSuffix(dev, ":"))
+			line = strings.TrimSpace(rest)
+			if line == "" {
+				continue
+			}
+		}
+		if cur < 0 {
+			start(devEntry)
+		}
+
+		p := &probes[cur]
+		if k, v, ok := strings.Cut(line, ":"); ok && k != "" {
+			p.Properties[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			continue
+		}
+		p.Lines = append(p.Lines, line)
+	}
+
+	return probes
+}
@@ -0,0 +1,40 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFsid(t *testing.T) {
+	out := `
+/dev/disk4: GUID_partition_scheme
+/dev/disk4s1:
+	Filesystem: HFS+
+	Volume name: My Volume
+	journaled
+/dev/disk4s2: Apple_Free
+`
+	got := parseFsid([]byte(out), "/dev/disk4")
+	want := []FilesystemProbe{
+		{DevEntry: "/dev/disk4", Properties: map[string]string{}, Lines: []string{"GUID_partition_scheme"}},
+		{DevEntry: "/dev/disk4s1", Properties: map[string]string{"Filesystem": "HFS+", "Volume name": "My Volume"}, Lines: []string{"journaled"}},
+		{DevEntry: "/dev/disk4s2", Properties: map[string]string{}, Lines: []string{"Apple_Free"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseFsidWithoutDevice(t *testing.T) {
+	got := parseFsid([]byte("Filesystem: FAT32\nno label\n"), "/dev/disk6s1")
+	want := []FilesystemProbe{
+		{DevEntry: "/dev/disk6s1", Properties: map[string]string{"Filesystem": "FAT32"}, Lines: []string{"no label"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
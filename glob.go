@@ -0,0 +1,90 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "strings"
+
+// globSpecialChars is the characters glob(3) interprets in a pattern, including the brace expansion ones.
+const globSpecialChars = `\*?[]{},`
+
+// Glob composes the single glob(3) expression accepted by the makehybrid hide and only flags, such as MakehybridHideAll, from a list of patterns.
+//
+// makehybrid accepts each of these flags only once, so several patterns must be combined with brace expansion.
+// Each pattern is used as a glob(3) pattern; use GlobLiteral for paths which should match literally.
+// Surrounding shell quotes are removed from the patterns because hdiutil is not run through a shell.
+//
+//	hdiutil.MakehybridHideAll(hdiutil.Glob{"*.DS_Store", "private/*"}.String())
+type Glob []string
+
+// String returns the glob(3) expression matching any of the patterns.
+func (g Glob) String() string {
+	patterns := make([]string, 0, len(g))
+	for _, p := range g {
+		p = unquoteGlob(p)
+		if p == "" {
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+
+	switch len(patterns) {
+	case 0:
+		return ""
+	case 1:
+		return patterns[0]
+	}
+
+	for i, p := range patterns {
+		patterns[i] = escapeGlobCommas(p)
+	}
+	return "{" + strings.Join(patterns, ",") + "}"
+}
+
+// GlobLiteral returns a glob(3) pattern matching path literally, escaping the glob special characters.
+func GlobLiteral(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(globSpecialChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// unquoteGlob removes the shell quotes surrounding p, which users commonly copy from hdiutil command lines.
+func unquoteGlob(p string) string {
+	p = strings.TrimSpace(p)
+	if len(p) >= 2 && (p[0] == '\'' || p[0] == '"') && p[len(p)-1] == p[0] {
+		return p[1 : len(p)-1]
+	}
+	return p
+}
+
+// escapeGlobCommas escapes the commas of p which are not inside of a brace expansion of p itself,
+// so that p stays a single alternative of the enclosing brace expansion.
+func escapeGlobCommas(p string) string {
+	var (
+		b       strings.Builder
+		depth   int
+		escaped bool
+	)
+	for _, r := range p {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '{':
+			depth++
+		case r == '}' && depth > 0:
+			depth--
+		case r == ',' && depth == 0:
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "testing"
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		g    Glob
+		want string
+	}{
+		{nil, ""},
+		{Glob{""}, ""},
+		{Glob{"*.DS_Store"}, "*.DS_Store"},
+		{Glob{"'*.DS_Store'"}, "*.DS_Store"},
+		{Glob{`"private/*"`, " "}, "private/*"},
+		{Glob{"*.DS_Store", "private/*"}, "{*.DS_Store,private/*}"},
+		{Glob{"a,b", "c"}, `{a\,b,c}`},
+		{Glob{"*.{jpg,png}", "tmp"}, "{*.{jpg,png},tmp}"},
+		{Glob{`a\,b`, "c"}, `{a\,b,c}`},
+	}
+	for _, tt := range tests {
+		if got := tt.g.String(); got != tt.want {
+			t.Errorf("%q.String() = %q, want %q", []string(tt.g), got, tt.want)
+		}
+	}
+}
+
+func TestGlobLiteral(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"plain/path.txt", "plain/path.txt"},
+		{"What?*", `What\?\*`},
+		{"[draft] {v1,v2}", `\[draft\] \{v1\,v2\}`},
+		{`back\slash`, `back\\slash`},
+	}
+	for _, tt := range tests {
+		if got := GlobLiteral(tt.path); got != tt.want {
+			t.Errorf("GlobLiteral(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -4,8 +4,11 @@
 
 package hdiutil
 
+//go:generate go run ./internal/flaggen -in flags.yaml -out flags_gen.go
+
 import (
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -48,23 +51,35 @@ func (e EncryptionType) String() string {
 	return fmt.Sprintf("EncryptionType(%d)", e)
 }
 
-func (e EncryptionType) attachFlag() []string     { return stringFlag("encryption", e.String()) }
-func (e EncryptionType) convertFlag() []string    { return stringFlag("encryption", e.String()) }
-func (e EncryptionType) makehybridFlag() []string { return stringFlag("encryption", e.String()) }
-func (e EncryptionType) verifyFlag() []string     { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) AttachFlag() []string     { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) ChecksumFlag() []string   { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) ConvertFlag() []string    { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) CreateFlag() []string     { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) ImageinfoFlag() []string  { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) MakehybridFlag() []string { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) SegmentFlag() []string    { return stringFlag("encryption", e.String()) }
+func (e EncryptionType) VerifyFlag() []string     { return stringFlag("encryption", e.String()) }
 
 type plist bool
 
-func (p plist) attachFlag() []string  { return boolFlag("plist", bool(p)) }
-func (p plist) convertFlag() []string { return boolFlag("plist", bool(p)) }
-func (p plist) verifyFlag() []string  { return boolFlag("plist", bool(p)) }
+func (p plist) AttachFlag() []string      { return boolFlag("plist", bool(p)) }
+func (p plist) ConvertFlag() []string     { return boolFlag("plist", bool(p)) }
+func (p plist) ImageinfoFlag() []string   { return boolFlag("plist", bool(p)) }
+func (p plist) InfoFlag() []string        { return boolFlag("plist", bool(p)) }
+func (p plist) IsencryptedFlag() []string { return boolFlag("plist", bool(p)) }
+func (p plist) PluginsFlag() []string     { return boolFlag("plist", bool(p)) }
+func (p plist) VerifyFlag() []string      { return boolFlag("plist", bool(p)) }
 
 type puppetstrings bool
 
-func (p puppetstrings) attachFlag() []string     { return boolFlag("puppetstrings", bool(p)) }
-func (p puppetstrings) convertFlag() []string    { return boolFlag("puppetstrings", bool(p)) }
-func (p puppetstrings) makehybridFlag() []string { return boolFlag("puppetstrings", bool(p)) }
-func (p puppetstrings) verifyFlag() []string     { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) AttachFlag() []string     { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) BurnFlag() []string       { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) ChecksumFlag() []string   { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) CreateFlag() []string     { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) ConvertFlag() []string    { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) MakehybridFlag() []string { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) SegmentFlag() []string    { return boolFlag("puppetstrings", bool(p)) }
+func (p puppetstrings) VerifyFlag() []string     { return boolFlag("puppetstrings", bool(p)) }
 
 // Srcimagekey specify a key/value pair for the disk image recognition system. (-imagekey is normally a synonym)
 type Srcimagekey map[string]string
@@ -76,10 +91,14 @@ func (s Srcimagekey) commonFlag() []string {
 	}
 	return stringFlag("srcimagekey", arg)
 }
-func (s Srcimagekey) attachFlag() []string     { return s.commonFlag() }
-func (s Srcimagekey) convertFlag() []string    { return s.commonFlag() }
-func (s Srcimagekey) createFlag() []string     { return s.commonFlag() }
-func (s Srcimagekey) makehybridFlag() []string { return s.commonFlag() }
+func (s Srcimagekey) AttachFlag() []string     { return s.commonFlag() }
+func (s Srcimagekey) ChecksumFlag() []string   { return s.commonFlag() }
+func (s Srcimagekey) ConvertFlag() []string    { return s.commonFlag() }
+func (s Srcimagekey) CreateFlag() []string     { return s.commonFlag() }
+func (s Srcimagekey) ImageinfoFlag() []string  { return s.commonFlag() }
+func (s Srcimagekey) MakehybridFlag() []string { return s.commonFlag() }
+func (s Srcimagekey) SegmentFlag() []string    { return s.commonFlag() }
+func (s Srcimagekey) VerifyFlag() []string     { return s.commonFlag() }
 
 // Tgtimagekey specify a key/value pair for any image created. (-imagekey is only a synonym if there is no input image).
 type Tgtimagekey map[string]string
@@ -91,9 +110,10 @@ func (t Tgtimagekey) commonFlag() []string {
 	}
 	return stringFlag("tgtimagekey", arg)
 }
-func (t Tgtimagekey) attachFlag() []string  { return t.commonFlag() }
-func (t Tgtimagekey) convertFlag() []string { return t.commonFlag() }
-func (t Tgtimagekey) createFlag() []string  { return t.commonFlag() }
+func (t Tgtimagekey) AttachFlag() []string  { return t.commonFlag() }
+func (t Tgtimagekey) ConvertFlag() []string { return t.commonFlag() }
+func (t Tgtimagekey) CreateFlag() []string  { return t.commonFlag() }
+func (t Tgtimagekey) SegmentFlag() []string { return t.commonFlag() }
 
 // Imagekey is normally a synonym to Srcimagekey, only a synonym Tgtimagekey if there is no input image.
 type Imagekey map[string]string
@@ -105,8 +125,8 @@ func (i Imagekey) commonFlag() []string {
 	}
 	return stringFlag("imagekey", arg)
 }
-func (i Imagekey) attachFlag() []string { return i.commonFlag() }
-func (i Imagekey) createFlag() []string { return i.commonFlag() }
+func (i Imagekey) AttachFlag() []string { return i.commonFlag() }
+func (i Imagekey) CreateFlag() []string { return i.commonFlag() }
 
 // Encryption specify a particular type of encryption or, if not specified, the default encryption algorithm.
 //
@@ -115,23 +135,53 @@ type Encryption EncryptionType
 
 type stdinpass bool
 
-func (s stdinpass) attachFlag() []string     { return boolFlag("stdinpass", bool(s)) }
-func (s stdinpass) convertFlag() []string    { return boolFlag("stdinpass", bool(s)) }
-func (s stdinpass) makehybridFlag() []string { return boolFlag("stdinpass", bool(s)) }
-func (s stdinpass) verifyFlag() []string     { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) AttachFlag() []string     { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) ChecksumFlag() []string   { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) ConvertFlag() []string    { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) CreateFlag() []string     { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) ImageinfoFlag() []string  { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) MakehybridFlag() []string { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) ResizeFlag() []string     { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) SegmentFlag() []string    { return boolFlag("stdinpass", bool(s)) }
+func (s stdinpass) VerifyFlag() []string     { return boolFlag("stdinpass", bool(s)) }
+
+// Passphrase is the passphrase of an encrypted image.
+//
+// It adds -stdinpass and writes the passphrase, null-terminated, to the standard input of hdiutil,
+// so it never appears in the command line of the process nor in the logged arguments.
+type Passphrase string
+
+func (p Passphrase) AttachFlag() []string    { return Stdinpass.AttachFlag() }
+func (p Passphrase) ChecksumFlag() []string  { return Stdinpass.ChecksumFlag() }
+func (p Passphrase) ConvertFlag() []string   { return Stdinpass.ConvertFlag() }
+func (p Passphrase) CreateFlag() []string    { return Stdinpass.CreateFlag() }
+func (p Passphrase) ImageinfoFlag() []string { return Stdinpass.ImageinfoFlag() }
+func (p Passphrase) ResizeFlag() []string    { return Stdinpass.ResizeFlag() }
+func (p Passphrase) SegmentFlag() []string   { return Stdinpass.SegmentFlag() }
+func (p Passphrase) VerifyFlag() []string    { return Stdinpass.VerifyFlag() }
+
+// stdin returns the standard input of hdiutil -stdinpass.
+func (p Passphrase) stdin() io.Reader {
+	return strings.NewReader(string(p) + "\x00")
+}
 
 type agentpass bool
 
 // Recover specify a keychain containing the secret corresponding to the certificate specified with -certificate when the image was created.
 type Recover string
 
-func (r Recover) attachFlag() []string { return stringFlag("recover", string(r)) }
+func (r Recover) args() []string { return stringFlag("recover", string(r)) }
+
+func (r Recover) AttachFlag() []string    { return r.args() }
+func (r Recover) ChecksumFlag() []string  { return r.args() }
+func (r Recover) ImageinfoFlag() []string { return r.args() }
+func (r Recover) VerifyFlag() []string    { return r.args() }
 
 // Certificate specify a secondary access certificate for an encrypted image.
 // cert_file must be DER-encoded certificate data, which can be created by Keychain Access or openssl(1).
 type Certificate string
 
-func (c Certificate) convertFlag() []string { return stringFlag("certificate", string(c)) }
+func (c Certificate) ConvertFlag() []string { return stringFlag("certificate", string(c)) }
 
 // Pubkey specify a list of public keys, identified by their hexadecimal hashes, to be used to protect the encrypted image being created.
 type Pubkey []string
@@ -142,8 +192,26 @@ type Pubkey []string
 // See also --capath and --cacert in curl(1).
 type Cacert string
 
+func (c Cacert) args() []string { return stringFlag("cacert", string(c)) }
+
+func (c Cacert) AttachFlag() []string    { return c.args() }
+func (c Cacert) BurnFlag() []string      { return c.args() }
+func (c Cacert) ChecksumFlag() []string  { return c.args() }
+func (c Cacert) ConvertFlag() []string   { return c.args() }
+func (c Cacert) ImageinfoFlag() []string { return c.args() }
+func (c Cacert) VerifyFlag() []string    { return c.args() }
+
 type insecurehttp bool
 
+func (i insecurehttp) args() []string { return boolFlag("insecurehttp", bool(i)) }
+
+func (i insecurehttp) AttachFlag() []string    { return i.args() }
+func (i insecurehttp) BurnFlag() []string      { return i.args() }
+func (i insecurehttp) ChecksumFlag() []string  { return i.args() }
+func (i insecurehttp) ConvertFlag() []string   { return i.args() }
+func (i insecurehttp) ImageinfoFlag() []string { return i.args() }
+func (i insecurehttp) VerifyFlag() []string    { return i.args() }
+
 // Shadow use a shadow file in conjunction with the data in the primary image file.
 // This option prevents modification of the original image and allows read-only images to be attached read/write.
 //
@@ -155,32 +223,43 @@ type insecurehttp bool
 // hdiutil verbs taking images as input accept -shadow, -cacert, and -insecurehttp.
 type Shadow string
 
-func (s Shadow) attachFlag() []string     { return stringFlag("shadow", string(s)) }
-func (s Shadow) convertFlag() []string    { return stringFlag("shadow", string(s)) }
-func (s Shadow) makehybridFlag() []string { return stringFlag("shadow", string(s)) }
-
-type verbose bool
-
-func (v verbose) attachFlag() []string     { return boolFlag("verbose", bool(v)) }
-func (v verbose) convertFlag() []string    { return boolFlag("verbose", bool(v)) }
-func (v verbose) createFlag() []string     { return boolFlag("verbose", bool(v)) }
-func (v verbose) detachFlag() []string     { return boolFlag("verbose", bool(v)) }
-func (v verbose) makehybridFlag() []string { return boolFlag("verbose", bool(v)) }
-
-type quiet bool
+func (s Shadow) args() []string { return stringFlag("shadow", string(s)) }
 
-func (q quiet) attachFlag() []string     { return boolFlag("quiet", bool(q)) }
-func (q quiet) createFlag() []string     { return boolFlag("quiet", bool(q)) }
-func (q quiet) detachFlag() []string     { return boolFlag("quiet", bool(q)) }
-func (q quiet) makehybridFlag() []string { return boolFlag("quiet", bool(q)) }
+func (s Shadow) AttachFlag() []string     { return s.args() }
+func (s Shadow) BurnFlag() []string       { return s.args() }
+func (s Shadow) ChecksumFlag() []string   { return s.args() }
+func (s Shadow) ConvertFlag() []string    { return s.args() }
+func (s Shadow) ImageinfoFlag() []string  { return s.args() }
+func (s Shadow) MakehybridFlag() []string { return s.args() }
+func (s Shadow) VerifyFlag() []string     { return s.args() }
 
-type debug bool
-
-func (d debug) attachFlag() []string     { return boolFlag("debug", bool(d)) }
-func (d debug) convertFlag() []string    { return boolFlag("debug", bool(d)) }
-func (d debug) createFlag() []string     { return boolFlag("debug", bool(d)) }
-func (d debug) detachFlag() []string     { return boolFlag("debug", bool(d)) }
-func (d debug) makehybridFlag() []string { return boolFlag("debug", bool(d)) }
+// globalFlag is a flag accepted by every verb with the same meaning, such as Verbose.
+//
+// It implements the flag interfaces of all the verbs once, instead of each option declaring the verbs it applies to.
+type globalFlag string
+
+func (g globalFlag) args() []string { return boolFlag(string(g), g != "") }
+
+func (g globalFlag) AttachFlag() []string      { return g.args() }
+func (g globalFlag) BurnFlag() []string        { return g.args() }
+func (g globalFlag) ChecksumFlag() []string    { return g.args() }
+func (g globalFlag) ConvertFlag() []string     { return g.args() }
+func (g globalFlag) CreateFlag() []string      { return g.args() }
+func (g globalFlag) DetachFlag() []string      { return g.args() }
+func (g globalFlag) EjectFlag() []string       { return g.args() }
+func (g globalFlag) FsidFlag() []string        { return g.args() }
+func (g globalFlag) ImageinfoFlag() []string   { return g.args() }
+func (g globalFlag) InfoFlag() []string        { return g.args() }
+func (g globalFlag) IsencryptedFlag() []string { return g.args() }
+func (g globalFlag) MakehybridFlag() []string  { return g.args() }
+func (g globalFlag) PluginsFlag() []string     { return g.args() }
+func (g globalFlag) PmapFlag() []string        { return g.args() }
+func (g globalFlag) ResizeFlag() []string      { return g.args() }
+func (g globalFlag) SegmentFlag() []string     { return g.args() }
+func (g globalFlag) UdifderezFlag() []string   { return g.args() }
+func (g globalFlag) UdifrezFlag() []string     { return g.args() }
+func (g globalFlag) UnmountFlag() []string     { return g.args() }
+func (g globalFlag) VerifyFlag() []string      { return g.args() }
 
 const (
 	// Plist provide result output in plist format.
@@ -217,33 +296,68 @@ const (
 	// This option can help the user decipher why a particular operation failed.
 	// At a minimum, the probing of any specified images will be detailed.
 	// BUG(zchee): not exit hdiutil command if set.
-	Verbose verbose = true
+	Verbose globalFlag = "verbose"
 
 	// Quiet close stdout and stderr, leaving only hdiutil's exit status to indicate success or failure.
 	// No /dev entries or mount points will be printed.
 	//
 	// -debug and -verbose disable -quiet.
 	// BUG(zchee): not get the command result such as device node path when attach.
-	Quiet quiet = true
+	Quiet globalFlag = "quiet"
 
 	// Debug be very verbose.
 	//
 	// This option is good if a large amount of progress information is needed.
 	// As of Mac OS X 10.6, -debug enables -verbose.
 	// BUG(zchee): not exit hdiutil command if set.
-	Debug debug = true
+	Debug globalFlag = "debug"
 )
 
+// DeviceNode is the device node of an attached image, such as /dev/disk2.
+type DeviceNode string
+
+func (d DeviceNode) String() string { return string(d) }
+
+// Raw returns the raw device node of d, such as /dev/rdisk2s1 for /dev/disk2s1.
+// d is returned unchanged if it is not a disk device node.
+func (d DeviceNode) Raw() DeviceNode {
+	m := diskNodeRe.FindStringSubmatch(string(d))
+	if m == nil {
+		return d
+	}
+	return DeviceNode("/dev/r" + m[1])
+}
+
+// Whole returns the whole disk device node of d, such as /dev/disk2 for /dev/disk2s1.
+// d is returned unchanged if it is not a disk device node.
+func (d DeviceNode) Whole() DeviceNode { return DeviceNode(wholeDiskNode(string(d))) }
+
+// Number returns the disk number of d, such as 2 for /dev/disk2s1, or an error if d is not a disk device node.
+func (d DeviceNode) Number() (int, error) {
+	m := wholeDiskRe.FindStringSubmatch(string(d))
+	if m == nil {
+		return 0, fmt.Errorf("%q is not a disk device node", string(d))
+	}
+	return strconv.Atoi(strings.TrimPrefix(m[1], "disk"))
+}
+
+// Slice returns the device node of the slice n of the whole disk of d, such as /dev/disk2s1 for Slice(1) of /dev/disk2.
+// d must be a disk device node, which Number checks.
+func (d DeviceNode) Slice(n int) DeviceNode {
+	return DeviceNode(string(d.Whole()) + "s" + strconv.Itoa(n))
+}
+
 // RawDeviceNode return the raw device node from the deviceNode.
+//
+// Deprecated: Use DeviceNode.Raw.
 func RawDeviceNode(deviceNode string) string {
-	return strings.Replace(deviceNode, "disk", "rdisk", 1)
+	return DeviceNode(deviceNode).Raw().String()
 }
 
-// DeviceNumber return the device number from the deviceNode.
+// DeviceNumber return the device number from the deviceNode, or 0 if it is not a disk device node.
+//
+// Deprecated: Use DeviceNode.Number, which reports the invalid device nodes.
 func DeviceNumber(deviceNode string) int {
-	n, err := strconv.Atoi(strings.TrimPrefix(deviceNode, "/dev/disk"))
-	if err != nil {
-		return 0
-	}
+	n, _ := DeviceNode(deviceNode).Number()
 	return n
 }
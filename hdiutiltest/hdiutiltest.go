@@ -0,0 +1,65 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hdiutiltest provides helpers to gate the tests which run hdiutil, so they skip cleanly where it is unavailable.
+package hdiutiltest
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// probeTimeout bounds the hdiutil probe, which hangs in some sandboxes instead of failing.
+const probeTimeout = 10 * time.Second
+
+var (
+	probeOnce sync.Once
+	probeErr  string
+)
+
+// RequireDarwin skips the test unless it runs on macOS.
+func RequireDarwin(t testing.TB) {
+	t.Helper()
+	if runtime.GOOS != "darwin" {
+		t.Skipf("requires macOS, running on %s", runtime.GOOS)
+	}
+}
+
+// RequireHdiutil skips the test unless hdiutil is available and usable, which is not the case off macOS
+// or in sandboxes without access to the DiskImages framework.
+//
+// hdiutil is probed once per test binary by running hdiutil info.
+func RequireHdiutil(t testing.TB) {
+	t.Helper()
+	RequireDarwin(t)
+
+	probeOnce.Do(func() {
+		path, err := exec.LookPath("hdiutil")
+		if err != nil {
+			probeErr = err.Error()
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		if out, err := exec.CommandContext(ctx, path, "info").CombinedOutput(); err != nil {
+			probeErr = "hdiutil info: " + err.Error() + ": " + string(out)
+		}
+	})
+	if probeErr != "" {
+		t.Skipf("requires a working hdiutil: %s", probeErr)
+	}
+}
+
+// RequireRoot skips the test unless it runs as root, as required by some verbs such as createinstallmedia.
+func RequireRoot(t testing.TB) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("requires root privileges")
+	}
+}
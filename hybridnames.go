@@ -0,0 +1,254 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// isoMaxNameLen is the maximum file identifier length of an ISO9660 Level 2 filesystem.
+	isoMaxNameLen = 31
+	// isoMaxDepth is the maximum directory hierarchy depth of an ISO9660 filesystem.
+	isoMaxDepth = 8
+	// isoMaxPathLen is the maximum path length of an ISO9660 filesystem.
+	isoMaxPathLen = 255
+	// jolietMaxNameLen is the maximum file identifier length, in UCS-2 characters, of a Joliet filesystem.
+	jolietMaxNameLen = 64
+)
+
+// jolietInvalidChars is the characters which may not be used in Joliet file identifiers.
+const jolietInvalidChars = `*/:;?\`
+
+// NameViolation describes a source file whose name can not be represented on a generated hybrid filesystem.
+type NameViolation struct {
+	// Path is the path of the file relative to the source directory.
+	Path string
+	// Filesystem is the filesystem that can not represent the name, "ISO9660" or "Joliet".
+	Filesystem string
+	// Reason is the violated constraint.
+	Reason string
+	// Suggestion is a suggested replacement for the last path element, or empty if renaming does not help.
+	Suggestion string
+}
+
+func (v NameViolation) String() string {
+	if v.Suggestion == "" {
+		return fmt.Sprintf("%s: %s: %s", v.Filesystem, v.Path, v.Reason)
+	}
+	return fmt.Sprintf("%s: %s: %s (suggest %q)", v.Filesystem, v.Path, v.Reason, v.Suggestion)
+}
+
+// NameViolationsError is returned by CheckHybridNames when any file name violates the filesystem constraints.
+type NameViolationsError []NameViolation
+
+func (e NameViolationsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d file names violate hybrid filesystem constraints", len(e))
+	for _, v := range e {
+		b.WriteString("\n\t")
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+type makehybridPreflight bool
+
+func (m makehybridPreflight) MakehybridFlag() []string { return nil }
+
+const (
+	// MakehybridPreflight walk the source directory with CheckHybridNames before running hdiutil,
+	// so that file names which violate the ISO9660/Joliet constraints fail fast instead of after a long image generation.
+	MakehybridPreflight makehybridPreflight = true
+)
+
+// CheckHybridNames walks source and reports the files whose names violate the ISO9660 or Joliet constraints (length, characters and depth) as a NameViolationsError.
+//
+// The filesystems to check are selected by flags the same way as Makehybrid.
+// If neither MakehybridISO nor MakeHybridJoliet is given and no other filesystem is selected, both are checked as hdiutil generates them by default.
+func CheckHybridNames(source string, flags ...MakehybridFlag) error {
+	iso, joliet := hybridNameFilesystems(flags)
+	if !iso && !joliet {
+		return nil
+	}
+
+	var violations NameViolationsError
+	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if iso {
+			violations = append(violations, checkISO9660Name(rel, info.IsDir())...)
+		}
+		if joliet {
+			violations = append(violations, checkJolietName(rel)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// hybridNameFilesystems reports whether the ISO9660 and Joliet filesystems are generated with flags.
+func hybridNameFilesystems(flags []MakehybridFlag) (iso, joliet bool) {
+	var selected bool
+	for _, flag := range flags {
+		switch flag.(type) {
+		case makehybridISO:
+			iso, selected = true, true
+		case makehybridJoliet:
+			joliet, selected = true, true
+		case makehybridHFS, makehybridUDF:
+			selected = true
+		}
+	}
+	if !selected {
+		return true, true
+	}
+	return iso, joliet
+}
+
+func checkISO9660Name(rel string, dir bool) []NameViolation {
+	var violations []NameViolation
+	name := filepath.Base(rel)
+
+	if depth := strings.Count(rel, string(filepath.Separator)) + 1; dir && depth > isoMaxDepth {
+		violations = append(violations, NameViolation{
+			Path:       rel,
+			Filesystem: "ISO9660",
+			Reason:     fmt.Sprintf("directory depth %d exceeds %d", depth, isoMaxDepth),
+		})
+	}
+	if len(rel) > isoMaxPathLen {
+		violations = append(violations, NameViolation{
+			Path:       rel,
+			Filesystem: "ISO9660",
+			Reason:     fmt.Sprintf("path length %d exceeds %d", len(rel), isoMaxPathLen),
+		})
+	}
+
+	var reason string
+	switch {
+	case len(name) > isoMaxNameLen:
+		reason = fmt.Sprintf("name length %d exceeds %d", len(name), isoMaxNameLen)
+	case dir && strings.Contains(name, "."):
+		reason = "directory name contains '.'"
+	case strings.Count(name, ".") > 1:
+		reason = "name contains more than one '.'"
+	case strings.IndexFunc(name, func(r rune) bool { return !isISO9660Char(r) && r != '.' }) >= 0:
+		reason = "name contains characters other than A-Z, 0-9 and '_'"
+	}
+	if reason != "" {
+		violations = append(violations, NameViolation{
+			Path:       rel,
+			Filesystem: "ISO9660",
+			Reason:     reason,
+			Suggestion: mangleISO9660Name(name, dir),
+		})
+	}
+
+	return violations
+}
+
+func checkJolietName(rel string) []NameViolation {
+	name := filepath.Base(rel)
+	runes := []rune(name)
+
+	var reason string
+	switch {
+	case len(utf16.Encode(runes)) > jolietMaxNameLen:
+		reason = fmt.Sprintf("name length %d exceeds %d UCS-2 characters", len(utf16.Encode(runes)), jolietMaxNameLen)
+	case strings.IndexFunc(name, isJolietInvalid) >= 0:
+		reason = "name contains characters not allowed in Joliet (control characters or " + jolietInvalidChars + ")"
+	case strings.IndexFunc(name, func(r rune) bool { return r > 0xFFFF }) >= 0:
+		reason = "name contains characters outside of the UCS-2 range"
+	default:
+		return nil
+	}
+
+	return []NameViolation{{
+		Path:       rel,
+		Filesystem: "Joliet",
+		Reason:     reason,
+		Suggestion: mangleJolietName(name),
+	}}
+}
+
+// isISO9660Char reports whether r is an ISO9660 d-character, ignoring case as hdiutil maps lowercase letters.
+func isISO9660Char(r rune) bool {
+	return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_'
+}
+
+func isJolietInvalid(r rune) bool {
+	return r < 0x20 || strings.ContainsRune(jolietInvalidChars, r)
+}
+
+// mangleISO9660Name returns name converted to ISO9660 d-characters and truncated to the ISO9660 Level 2 length, preserving the extension of files.
+func mangleISO9660Name(name string, dir bool) string {
+	mapping := func(r rune) rune {
+		if isISO9660Char(r) {
+			return r
+		}
+		return '_'
+	}
+
+	base, ext := name, ""
+	if i := strings.LastIndex(name, "."); !dir && i > 0 {
+		base, ext = name[:i], name[i+1:]
+	}
+	base = strings.ToUpper(strings.Map(mapping, base))
+	ext = strings.ToUpper(strings.Map(mapping, ext))
+
+	if ext == "" {
+		if len(base) > isoMaxNameLen {
+			base = base[:isoMaxNameLen]
+		}
+		return base
+	}
+	if len(ext) > isoMaxNameLen-2 {
+		ext = ext[:isoMaxNameLen-2]
+	}
+	if max := isoMaxNameLen - len(ext) - 1; len(base) > max {
+		base = base[:max]
+	}
+	return base + "." + ext
+}
+
+// mangleJolietName returns name with the Joliet invalid characters replaced and truncated to the Joliet length, preserving the extension.
+func mangleJolietName(name string) string {
+	runes := []rune(strings.Map(func(r rune) rune {
+		if isJolietInvalid(r) || r > 0xFFFF {
+			return '_'
+		}
+		return r
+	}, name))
+	if len(runes) <= jolietMaxNameLen {
+		return string(runes)
+	}
+
+	ext := []rune(filepath.Ext(string(runes)))
+	if len(ext) >= jolietMaxNameLen {
+		ext = nil
+	}
+	base := runes[:jolietMaxNameLen-len(ext)]
+	return string(base) + string(ext)
+}
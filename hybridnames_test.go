@@ -0,0 +1,111 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckISO9660Name(t *testing.T) {
+	tests := []struct {
+		rel        string
+		dir        bool
+		reason     string
+		suggestion string
+	}{
+		{"README.TXT", false, "", ""},
+		{"readme.txt", false, "", ""},
+		{"DIR", true, "", ""},
+		{"my file.txt", false, "characters", "MY_FILE.TXT"},
+		{"archive.tar.gz", false, "more than one", "ARCHIVE_TAR.GZ"},
+		{"app.bundle", true, "directory name", "APP_BUNDLE"},
+		{strings.Repeat("A", 40) + ".TXT", false, "name length 44", strings.Repeat("A", 27) + ".TXT"},
+		{filepath.Join("A", "B", "C", "D", "E", "F", "G", "H", "I"), true, "depth 9", ""},
+	}
+	for _, tt := range tests {
+		got := checkISO9660Name(tt.rel, tt.dir)
+		if tt.reason == "" {
+			if len(got) != 0 {
+				t.Errorf("checkISO9660Name(%q) = %v, want none", tt.rel, got)
+			}
+			continue
+		}
+		if len(got) != 1 || !strings.Contains(got[0].Reason, tt.reason) || got[0].Suggestion != tt.suggestion {
+			t.Errorf("checkISO9660Name(%q) = %v, want %q suggesting %q", tt.rel, got, tt.reason, tt.suggestion)
+		}
+	}
+}
+
+func TestCheckJolietName(t *testing.T) {
+	long := strings.Repeat("é", 70) + ".txt"
+	tests := []struct {
+		name       string
+		reason     string
+		suggestion string
+	}{
+		{"Résumé 2017.pdf", "", ""},
+		{"what?.txt", "not allowed", "what_.txt"},
+		{"a:b*c", "not allowed", "a_b_c"},
+		{"emoji \U0001F600.txt", "UCS-2", "emoji _.txt"},
+		{long, "exceeds 64", strings.Repeat("é", 60) + ".txt"},
+	}
+	for _, tt := range tests {
+		got := checkJolietName(tt.name)
+		if tt.reason == "" {
+			if len(got) != 0 {
+				t.Errorf("checkJolietName(%q) = %v, want none", tt.name, got)
+			}
+			continue
+		}
+		if len(got) != 1 || !strings.Contains(got[0].Reason, tt.reason) || got[0].Suggestion != tt.suggestion {
+			t.Errorf("checkJolietName(%q) = %v, want %q suggesting %q", tt.name, got, tt.reason, tt.suggestion)
+		}
+	}
+}
+
+func TestHybridNameFilesystems(t *testing.T) {
+	tests := []struct {
+		flags       []MakehybridFlag
+		iso, joliet bool
+	}{
+		{nil, true, true},
+		{[]MakehybridFlag{MakehybridISO}, true, false},
+		{[]MakehybridFlag{MakeHybridJoliet}, false, true},
+		{[]MakehybridFlag{MakehybridISO, MakeHybridJoliet}, true, true},
+		{[]MakehybridFlag{MakehybridHFS}, false, false},
+		{[]MakehybridFlag{MakehybridHFS, MakeHybridJoliet}, false, true},
+	}
+	for _, tt := range tests {
+		if iso, joliet := hybridNameFilesystems(tt.flags); iso != tt.iso || joliet != tt.joliet {
+			t.Errorf("hybridNameFilesystems(%v) = %v, %v, want %v, %v", tt.flags, iso, joliet, tt.iso, tt.joliet)
+		}
+	}
+}
+
+func TestCheckHybridNames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"OK.TXT", "bad name?.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := CheckHybridNames(dir, MakehybridHFS); err != nil {
+		t.Errorf("HFS only: %v", err)
+	}
+
+	err := CheckHybridNames(dir)
+	var violations NameViolationsError
+	if !errors.As(err, &violations) {
+		t.Fatalf("got %v, want NameViolationsError", err)
+	}
+	if len(violations) != 2 || violations[0].Filesystem != "ISO9660" || violations[1].Filesystem != "Joliet" {
+		t.Errorf("violations = %v", violations)
+	}
+}
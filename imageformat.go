@@ -0,0 +1,96 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// ImageFormat is the format of a disk image, derived from hdiutil imageinfo.
+type ImageFormat struct {
+	// Format is the image format, or zero if it is not one of the Format constants.
+	Format Format
+
+	// Name is the format name reported by imageinfo, such as UDZO, including the formats without a Format constant.
+	Name string
+
+	// Compressed reports whether the image data is compressed, and Compression is the compression algorithm, such as zlib,
+	// or empty if it is not known.
+	Compressed  bool
+	Compression string
+
+	Encrypted   bool
+	Partitioned bool
+
+	// Segments is the number of segment files of the image, 1 if it is not segmented.
+	Segments int
+}
+
+// formatCompressions is the compression algorithm of the compressed formats.
+var formatCompressions = map[string]string{
+	"UDCO": "adc",
+	"UDZO": "zlib",
+	"UDBZ": "bzip2",
+	"ULFO": "lzfse",
+	"ULMO": "lzma",
+}
+
+// IsCompressed reports whether the image data is compressed.
+func (f ImageFormat) IsCompressed() bool { return f.Compressed }
+
+// IsSparse reports whether the image is a sparse image or a sparse bundle, which grows with its content.
+func (f ImageFormat) IsSparse() bool { return f.Format == ConvertUDSP || f.Format == ConvertUDSB }
+
+// IsWritable reports whether the image format can be attached read/write.
+func (f ImageFormat) IsWritable() bool {
+	switch f.Format {
+	case ConvertUDRW, ConvertUDSP, ConvertUDSB, ConvertRdWr:
+		return true
+	}
+	return false
+}
+
+// ImageFormatOf returns the format of image.
+//
+// The segments of a segmented image are counted from the files next to image, named like image.002.dmgpart.
+func ImageFormatOf(image string, flags ...ImageinfoFlag) (ImageFormat, error) {
+	return DefaultClient.ImageFormatOf(image, flags...)
+}
+
+// ImageFormatOf is like the package-level ImageFormatOf, but runs hdiutil with the configuration of c.
+func (c *Client) ImageFormatOf(image string, flags ...ImageinfoFlag) (ImageFormat, error) {
+	info, err := c.ImageInfo(image, flags...)
+	if err != nil {
+		return ImageFormat{}, err
+	}
+
+	f := info.ImageFormat()
+	f.Segments += len(segmentParts(image))
+
+	return f, nil
+}
+
+// ImageFormat returns the format of the image described by i, not counting its additional segments.
+func (i *DiskImageInfo) ImageFormat() ImageFormat {
+	f := ImageFormat{
+		Format:      parseFormat(i.Format),
+		Name:        i.Format,
+		Compressed:  i.Properties.Compressed,
+		Compression: formatCompressions[i.Format],
+		Encrypted:   i.Properties.Encrypted,
+		Partitioned: i.Properties.Partitioned,
+		Segments:    1,
+	}
+	if f.Compression != "" {
+		f.Compressed = true
+	}
+	return f
+}
+
+// parseFormat returns the Format named name, or zero if there is none.
+func parseFormat(name string) Format {
+	for f := ConvertUDRW; f <= ConvertDC42; f <<= 1 {
+		if f.String() == name {
+			return f
+		}
+	}
+	return 0
+}
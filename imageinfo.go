@@ -0,0 +1,83 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// DiskImageInfo is the information of a disk image reported by hdiutil imageinfo.
+type DiskImageInfo struct {
+	// Format is the image format, such as UDZO or UDSP.
+	Format string `plist:"Format"`
+
+	// FormatDescription is the human-readable description of Format.
+	FormatDescription string `plist:"Format Description"`
+
+	// ChecksumType is the type of the checksum stored in the image, such as CRC32.
+	ChecksumType string `plist:"Checksum Type"`
+
+	// ChecksumValue is the checksum stored in the image.
+	ChecksumValue string `plist:"Checksum Value"`
+
+	SizeInformation ImageSizeInfo   `plist:"Size Information"`
+	Properties      ImageProperties `plist:"Properties"`
+
+	// Raw is the property list output of hdiutil imageinfo, holding the keys which are not decoded into the fields.
+	Raw []byte `plist:"-"`
+}
+
+// ImageSizeInfo is the size information of a disk image.
+type ImageSizeInfo struct {
+	TotalBytes         int64   `plist:"Total Bytes"`
+	CompressedBytes    int64   `plist:"Compressed Bytes"`
+	CompressedRatio    float64 `plist:"Compressed Ratio"`
+	SectorCount        int64   `plist:"Sector Count"`
+	TotalEmptyBytes    int64   `plist:"Total Empty Bytes"`
+	TotalNonEmptyBytes int64   `plist:"Total Non-Empty Bytes"`
+}
+
+// ImageProperties is the properties of a disk image.
+type ImageProperties struct {
+	Checksummed      bool `plist:"Checksummed"`
+	Compressed       bool `plist:"Compressed"`
+	Encrypted        bool `plist:"Encrypted"`
+	KernelCompatible bool `plist:"Kernel Compatible"`
+	Partitioned      bool `plist:"Partitioned"`
+	SoftwareLicense  bool `plist:"Software License"`
+}
+
+// ImageinfoFlag is a hdiutil imageinfo command flag, returning its command-line arguments.
+type ImageinfoFlag interface {
+	ImageinfoFlag() []string
+}
+
+// ImageInfo print out information about a disk image.
+func ImageInfo(image string, flags ...ImageinfoFlag) (*DiskImageInfo, error) {
+	return DefaultClient.ImageInfo(image, flags...)
+}
+
+// ImageInfo is like the package-level ImageInfo, but runs hdiutil with the configuration of c.
+func (c *Client) ImageInfo(image string, flags ...ImageinfoFlag) (*DiskImageInfo, error) {
+	cmd := c.plistCommand("imageinfo")
+	cmd.target = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.ImageinfoFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	out, err := c.runPlist(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseImageInfoPlist(out)
+}
+
+// ParseImageInfoPlist decodes the hdiutil imageinfo -plist output data.
+func ParseImageInfoPlist(data []byte) (*DiskImageInfo, error) {
+	info := new(DiskImageInfo)
+	if err := unmarshalPlist(data, info); err != nil {
+		return nil, err
+	}
+	info.Raw = rawPlist(data)
+	return info, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// SystemImagesInfo is the information about the DiskImages framework and the currently attached images, reported by hdiutil info.
+type SystemImagesInfo struct {
+	// Framework is the DiskImages framework version.
+	Framework string `plist:"framework"`
+
+	// Driver is the version of the disk image kernel driver.
+	Driver string `plist:"driver"`
+
+	// Revision and Vendor is the revision and vendor of the DiskImages framework.
+	Revision string `plist:"revision"`
+	Vendor   string `plist:"vendor"`
+
+	// Images is the currently attached images.
+	Images []InfoImage `plist:"images"`
+
+	// Raw is the property list output of hdiutil info.
+	Raw []byte `plist:"-"`
+}
+
+// InfoImage is an attached image reported by hdiutil info.
+type InfoImage struct {
+	// ImagePath is the path of the image file.
+	ImagePath string `plist:"image-path"`
+
+	// ImageType is the human-readable type of the image, such as "read-only disk image".
+	ImageType string `plist:"image-type"`
+
+	// ShadowPath is the path of the shadow file, or empty if the image is attached without a shadow file.
+	ShadowPath string `plist:"shadow-path"`
+
+	// Writeable reports whether the image is attached read/write.
+	Writeable bool `plist:"writeable"`
+
+	// Encrypted reports whether the image is encrypted.
+	Encrypted bool `plist:"image-encrypted"`
+
+	// Removable reports whether the image can be detached, which it cannot if attached with AttachNotRemovable.
+	Removable bool `plist:"removable"`
+
+	// BlockCount is the size of the attached device in blocks of BlockSize bytes.
+	BlockCount int64 `plist:"blockcount"`
+	BlockSize  int64 `plist:"blocksize"`
+
+	// IconPath is the path of the icon of the device, if the image provides one.
+	IconPath string `plist:"icon-path"`
+
+	// OwnerUID is the user ID of the user who attached the image.
+	OwnerUID int `plist:"owner-uid"`
+
+	// HdidPID is the process ID of the helper process serving the image, or zero if it is attached in-kernel.
+	HdidPID int `plist:"hdid-pid"`
+
+	// SystemEntities is the device entries created for the image, the whole disk first.
+	SystemEntities []SystemEntity `plist:"system-entities"`
+}
+
+// Size returns the size of the attached device.
+func (i InfoImage) Size() Size { return Size(i.BlockCount * i.BlockSize) }
+
+// SystemEntity is a device entry of an attached image.
+type SystemEntity struct {
+	// DevEntry is the device node path, such as /dev/disk2s1.
+	DevEntry string `plist:"dev-entry"`
+
+	// ContentHint is the partition scheme or partition type, such as GUID_partition_scheme or Apple_HFS.
+	ContentHint string `plist:"content-hint"`
+
+	// MountPoint is the path the filesystem of the entry is mounted on, or empty if it is not mounted.
+	MountPoint string `plist:"mount-point"`
+
+	// VolumeKind is the kind of the mounted filesystem, such as hfs or apfs.
+	VolumeKind string `plist:"volume-kind"`
+
+	// UnmappedContentHint is the partition type as stored in the partition map, such as a GUID, which ContentHint maps to a name.
+	UnmappedContentHint string `plist:"unmapped-content-hint"`
+
+	// PotentiallyMountable reports whether the entry holds a filesystem which can be mounted.
+	PotentiallyMountable bool `plist:"potentially-mountable"`
+}
+
+// InfoFlag is a hdiutil info command flag, returning its command-line arguments.
+type InfoFlag interface {
+	InfoFlag() []string
+}
+
+// Info display information about the DiskImages framework and the currently attached images.
+//
+// It runs hdiutil info -plist. Each attached image lists its device entries with their mount points,
+// see InfoImage.DeviceNode and InfoImage.MountPoints, and SystemImagesInfo.Filter to find stale attachments.
+func Info(flags ...InfoFlag) (*SystemImagesInfo, error) {
+	return DefaultClient.Info(flags...)
+}
+
+// Info is like the package-level Info, but runs hdiutil with the configuration of c.
+func (c *Client) Info(flags ...InfoFlag) (*SystemImagesInfo, error) {
+	cmd := c.plistCommand("info")
+	for _, flag := range flags {
+		cmd.flag(flag, flag.InfoFlag())
+	}
+
+	out, err := c.runPlist(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseInfoPlist(out)
+}
+
+// ParseInfoPlist decodes the hdiutil info -plist output data.
+func ParseInfoPlist(data []byte) (*SystemImagesInfo, error) {
+	info := new(SystemImagesInfo)
+	if err := unmarshalPlist(data, info); err != nil {
+		return nil, err
+	}
+	info.Raw = rawPlist(data)
+	return info, nil
+}
+
+// AttachedImage is an operator view of an attached image, aggregating its Info entries.
+type AttachedImage struct {
+	// ImagePath is the path of the image file.
+	ImagePath string
+
+	// DeviceNodes is the device nodes of the image, the whole disk first.
+	DeviceNodes []string
+
+	// MountPoints is the mount points of the image volumes.
+	MountPoints []string
+
+	// Writable reports whether the image is attached read/write.
+	Writable bool
+
+	// Shadowed reports whether the image is attached with a shadow file, in which case ShadowPath is its path.
+	Shadowed   bool
+	ShadowPath string
+}
+
+// ListAttachedImages returns the currently attached images.
+func ListAttachedImages() ([]AttachedImage, error) {
+	return DefaultClient.ListAttachedImages()
+}
+
+// ListAttachedImages is like the package-level ListAttachedImages, but runs hdiutil with the configuration of c.
+func (c *Client) ListAttachedImages() ([]AttachedImage, error) {
+	info, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]AttachedImage, 0, len(info.Images))
+	for _, img := range info.Images {
+		a := AttachedImage{
+			ImagePath:   img.ImagePath,
+			MountPoints: img.MountPoints(),
+			Writable:    img.Writeable,
+			Shadowed:    img.ShadowPath != "",
+			ShadowPath:  img.ShadowPath,
+		}
+		for _, e := range img.SystemEntities {
+			a.DeviceNodes = append(a.DeviceNodes, e.DevEntry)
+		}
+		images = append(images, a)
+	}
+
+	return images, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// attachments records the images attached by this process, keyed by the whole disk device node.
+var attachments = struct {
+	sync.Mutex
+	m map[string]time.Time
+}{m: make(map[string]time.Time)}
+
+func recordAttach(deviceNode string) {
+	attachments.Lock()
+	attachments.m[wholeDiskNode(deviceNode)] = time.Now()
+	attachments.Unlock()
+}
+
+func forgetAttach(deviceNode string) {
+	attachments.Lock()
+	delete(attachments.m, wholeDiskNode(deviceNode))
+	attachments.Unlock()
+}
+
+var wholeDiskRe = regexp.MustCompile(`^(?:/dev/)?r?(disk[\d]+)(?:s[\d]+)*$`)
+
+// wholeDiskNode returns the whole disk device node of deviceNode, such as /dev/disk2 for /dev/rdisk2s1.
+// deviceNode is returned unchanged if it is not a disk device node.
+func wholeDiskNode(deviceNode string) string {
+	m := wholeDiskRe.FindStringSubmatch(deviceNode)
+	if m == nil {
+		return deviceNode
+	}
+	return "/dev/" + m[1]
+}
+
+var diskNodeRe = regexp.MustCompile(`^(?:/dev/)?r?(disk[\d]+(?:s[\d]+)*)$`)
+
+// blockDeviceNode returns the block device node of deviceNode, such as /dev/disk2s1 for rdisk2s1.
+// deviceNode is returned unchanged if it is not a disk device node.
+func blockDeviceNode(deviceNode string) string {
+	m := diskNodeRe.FindStringSubmatch(deviceNode)
+	if m == nil {
+		return deviceNode
+	}
+	return "/dev/" + m[1]
+}
+
+// InfoQuery selects attached images from the Info results.
+//
+// Empty fields match any image. Paths are compared after filepath.Clean,
+// and Device accepts the raw (/dev/rdiskN) and short (diskN) forms of the device node.
+type InfoQuery struct {
+	// Image is the path of the image file.
+	Image string
+
+	// Device is a device node of any of the image entries.
+	Device string
+
+	// MountPoint is a mount point of any of the image entries.
+	MountPoint string
+}
+
+// Match reports whether img matches q.
+func (q InfoQuery) Match(img InfoImage) bool {
+	if q.Image != "" && filepath.Clean(q.Image) != filepath.Clean(img.ImagePath) {
+		return false
+	}
+	if q.Device != "" && !img.hasEntity(func(e SystemEntity) bool { return e.DevEntry == blockDeviceNode(q.Device) }) {
+		return false
+	}
+	if q.MountPoint != "" && !img.hasEntity(func(e SystemEntity) bool {
+		return e.MountPoint != "" && filepath.Clean(e.MountPoint) == filepath.Clean(q.MountPoint)
+	}) {
+		return false
+	}
+	return true
+}
+
+func (img InfoImage) hasEntity(fn func(SystemEntity) bool) bool {
+	for _, e := range img.SystemEntities {
+		if fn(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceNode returns the whole disk device node of img, or empty if it has no device entries.
+func (img InfoImage) DeviceNode() DeviceNode {
+	if len(img.SystemEntities) == 0 {
+		return ""
+	}
+	return DeviceNode(img.SystemEntities[0].DevEntry).Whole()
+}
+
+// MountPoints returns the mount points of the volumes of img.
+func (img InfoImage) MountPoints() []string {
+	return entityMountPoints(img.SystemEntities)
+}
+
+// entityMountPoints returns the mount points of the mounted entities.
+func entityMountPoints(entities []SystemEntity) []string {
+	var mountPoints []string
+	for _, e := range entities {
+		if e.MountPoint != "" {
+			mountPoints = append(mountPoints, e.MountPoint)
+		}
+	}
+	return mountPoints
+}
+
+// AttachTime returns the time img was attached.
+//
+// The time is exact for images attached by this process.
+// Otherwise it is approximated by the modification time of the device node, which is created when the image is attached.
+func (img InfoImage) AttachTime() (time.Time, error) {
+	dev := img.DeviceNode().String()
+
+	attachments.Lock()
+	t, ok := attachments.m[dev]
+	attachments.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	fi, err := os.Stat(dev)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// InfoPredicate reports whether an attached image should be selected.
+type InfoPredicate func(InfoImage) bool
+
+// AttachedByThisProcess returns an InfoPredicate selecting the images attached with Attach by the current process.
+func AttachedByThisProcess() InfoPredicate {
+	return func(img InfoImage) bool {
+		attachments.Lock()
+		defer attachments.Unlock()
+		_, ok := attachments.m[img.DeviceNode().String()]
+		return ok
+	}
+}
+
+// OlderThan returns an InfoPredicate selecting the images attached longer than d ago.
+// Images whose attach time can not be determined are not selected.
+func OlderThan(d time.Duration) InfoPredicate {
+	return func(img InfoImage) bool {
+		t, err := img.AttachTime()
+		return err == nil && time.Since(t) > d
+	}
+}
+
+// Filter returns the attached images matching q and all of preds.
+func (s *SystemImagesInfo) Filter(q InfoQuery, preds ...InfoPredicate) []InfoImage {
+	var images []InfoImage
+	for _, img := range s.Images {
+		if !q.Match(img) {
+			continue
+		}
+		ok := true
+		for _, pred := range preds {
+			if !pred(img) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			images = append(images, img)
+		}
+	}
+	return images
+}
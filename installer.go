@@ -0,0 +1,108 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrNeedsRoot is returned when an operation requires root privileges and the process is not running as root.
+var ErrNeedsRoot = errors.New("operation requires root privileges")
+
+// installMediaOverhead is the free space reserved on top of the installer application size.
+// createinstallmedia copies the whole application plus its boot files, and needs the slack for the filesystem structures.
+const installMediaOverhead = 1 << 30
+
+// CreateInstallMedia creates a bootable macOS installer disk image at image from the macOS installer application app, such as "/Applications/Install macOS Mojave.app".
+//
+// The image is sized from the installer application, attached to a private mount point, populated by the createinstallmedia tool inside app and detached again.
+// A ".dmg" extension is appended to image if missing, as hdiutil create does.
+// createinstallmedia requires root privileges, so CreateInstallMedia returns ErrNeedsRoot unless the process runs as root
+// or the Client uses WithSudo. It erases the volume of the image, so it is audited and confirmed, see WithAuditWriter and WithConfirm.
+// The returns created image path and error.
+func CreateInstallMedia(app, image string) (string, error) {
+	return DefaultClient.CreateInstallMedia(app, image)
+}
+
+// CreateInstallMedia is like the package-level CreateInstallMedia, but runs hdiutil and createinstallmedia with the configuration of c.
+func (c *Client) CreateInstallMedia(app, image string) (string, error) {
+	if os.Geteuid() != 0 && !c.sudo {
+		return "", ErrNeedsRoot
+	}
+
+	tool := filepath.Join(app, "Contents", "Resources", "createinstallmedia")
+	if _, err := os.Stat(tool); err != nil {
+		return "", fmt.Errorf("%s is not a macOS installer application: %w", app, err)
+	}
+
+	size, err := installMediaSize(app)
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.Ext(image) != ".dmg" {
+		image += ".dmg"
+	}
+	if _, err := c.Create(image, size, CreateJHFSPlus, CreateLayout("GPTSPUD"), CreateVolname("Install")); err != nil {
+		return "", fmt.Errorf("create %s: %w", image, err)
+	}
+
+	mountPoint, err := os.MkdirTemp(c.tempDir(), "hdiutil-installmedia")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(mountPoint)
+
+	// createinstallmedia erases and renames the volume, so the ownership of the files it writes must be honored.
+	attached, err := c.Attach(image, AttachMountPoint(mountPoint), AttachNoBrowse, AttachOwnersOn, AttachNoVerify)
+	if err != nil {
+		return "", fmt.Errorf("attach %s: %w", image, err)
+	}
+	deviceNode := attached.DeviceNode().String()
+
+	cmd := c.toolCommand(tool, "--volume", mountPoint, "--nointeraction")
+	cmd.target, cmd.output = mountPoint, image
+	cmd.modifies, cmd.destructive, cmd.sudo = true, true, true
+	if _, _, err := c.runTool(cmd); err != nil {
+		// the volume may still be busy right after the failure, so do not leave the image attached.
+		c.Detach(deviceNode, DetachForce, internalCall())
+		return "", err
+	}
+
+	// createinstallmedia remounts the volume under /Volumes, but the device node is unchanged.
+	if err := c.Detach(deviceNode, internalCall()); err != nil {
+		if err := c.Detach(deviceNode, DetachForce, internalCall()); err != nil {
+			return "", fmt.Errorf("detach %s: %w", deviceNode, err)
+		}
+	}
+
+	return image, nil
+}
+
+// installMediaSize returns the image size needed to hold the installer application app.
+func installMediaSize(app string) (CreateSize, error) {
+	var total int64
+	err := filepath.Walk(app, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	total += total/10 + installMediaOverhead
+	gigabytes := (total + 1<<30 - 1) >> 30
+
+	return CreateSize(strconv.FormatInt(gigabytes, 10) + "g"), nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateInstallMedia(t *testing.T) {
+	app := filepath.Join(t.TempDir(), "Install macOS.app")
+	tool := filepath.Join(app, "Contents", "Resources", "createinstallmedia")
+	if err := os.MkdirAll(filepath.Dir(tool), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tool, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(t.TempDir(), "Install")
+	attach := readTestdata(t, "attach.plist")
+	hdiutil := WithRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		switch args[0] {
+		case "create":
+			created, err := marshalPlist([]string{image + ".dmg"})
+			return created, nil, err
+		case "attach":
+			return attach, nil, nil
+		}
+		return nil, nil, nil
+	}))
+
+	var ran []string
+	var audit bytes.Buffer
+	c := NewClient(hdiutil, WithSudo(), WithAuditWriter(&audit), WithTempDir(t.TempDir()),
+		WithToolRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+			ran = append(ran, strings.Join(args, " "))
+			return nil, nil, nil
+		})))
+	if _, err := c.CreateInstallMedia(app, image); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 || !strings.HasPrefix(ran[0], tool+" --volume ") || !strings.HasSuffix(ran[0], " --nointeraction") {
+		t.Errorf("ran %q, want %s", ran, tool)
+	}
+	if !strings.Contains(audit.String(), "createinstallmedia") {
+		t.Errorf("createinstallmedia not audited: %s", audit.String())
+	}
+
+	ran = nil
+	declined := errors.New("declined")
+	c = NewClient(hdiutil, WithSudo(), WithTempDir(t.TempDir()), WithConfirm(func(op Operation) error {
+		if op.Verb == tool {
+			return declined
+		}
+		return nil
+	}), WithToolRunner(RunnerFunc(func(ctx context.Context, args []string, stdin io.Reader) ([]byte, []byte, error) {
+		ran = append(ran, strings.Join(args, " "))
+		return nil, nil, nil
+	})))
+	if _, err := c.CreateInstallMedia(app, image); !errors.Is(err, ErrNotConfirmed) || len(ran) != 0 {
+		t.Errorf("got %v, ran %q, want ErrNotConfirmed", err, ran)
+	}
+}
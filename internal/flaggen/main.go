@@ -0,0 +1,328 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command flaggen generates the plain hdiutil flag types and the verb tables validating the flags of an invocation from flags.yaml.
+//
+// It is run by go generate in the hdiutil package:
+//
+//	go run ./internal/flaggen -in flags.yaml -out flags_gen.go
+//
+// flags.yaml is a YAML list of flat mappings with scalar values. A key may be repeated to give several values.
+// Each entry has a kind:
+//
+//	kind: flag   a verb flag type, with the keys type, name, style (bool, boolno, string or int),
+//	             verb (the verbs accepting it, space separated), doc and const ("Name value doc", repeatable).
+//	kind: enum   a string type with a String method and a table of its valid values,
+//	             with the keys type, doc and const.
+//	kind: verb   the flags accepted by a verb, with the keys name and accepts (space separated, repeatable).
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// entry is a mapping of the spec. Repeated keys accumulate their values.
+type entry struct {
+	line   int
+	values map[string][]string
+}
+
+func (e *entry) get(key string) string {
+	if v := e.values[key]; len(v) > 0 {
+		return v[len(v)-1]
+	}
+	return ""
+}
+
+func (e *entry) fields(key string) []string {
+	var fields []string
+	for _, v := range e.values[key] {
+		fields = append(fields, strings.Fields(v)...)
+	}
+	return fields
+}
+
+// parse parses the YAML subset used by the spec.
+func parse(r io.Reader) ([]*entry, error) {
+	var entries []*entry
+	var cur *entry
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "- "):
+			cur = &entry{line: n, values: make(map[string][]string)}
+			entries = append(entries, cur)
+			trimmed = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, " ") && cur != nil:
+		default:
+			return nil, fmt.Errorf("line %d: expected a list item or an indented key", n)
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		cur.values[strings.TrimSpace(key)] = append(cur.values[strings.TrimSpace(key)], value)
+	}
+
+	return entries, s.Err()
+}
+
+// constant is a constant of a generated type.
+type constant struct {
+	name, value, doc string
+}
+
+// parseConst parses a const value "Name value doc". value may be double quoted.
+func parseConst(s string) (constant, error) {
+	name, rest, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return constant{}, fmt.Errorf("const %q: missing value", s)
+	}
+	rest = strings.TrimSpace(rest)
+
+	var value string
+	if strings.HasPrefix(rest, `"`) {
+		end := strings.Index(rest[1:], `"`)
+		if end < 0 {
+			return constant{}, fmt.Errorf("const %q: unterminated value", s)
+		}
+		value, rest = rest[:end+2], rest[end+2:]
+	} else {
+		value, rest, _ = strings.Cut(rest, " ")
+	}
+
+	return constant{name: name, value: value, doc: strings.TrimSpace(rest)}, nil
+}
+
+func (e *entry) consts() ([]constant, error) {
+	var consts []constant
+	for _, v := range e.values["const"] {
+		c, err := parseConst(v)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", e.line, err)
+		}
+		consts = append(consts, c)
+	}
+	return consts, nil
+}
+
+// methodName returns the flag interface method of verb, such as ImageinfoFlag for imageinfo.
+func methodName(verb string) string {
+	return strings.ToUpper(verb[:1]) + verb[1:] + "Flag"
+}
+
+// goType is the underlying type of the flag styles.
+var goType = map[string]string{
+	"bool":   "bool",
+	"boolno": "bool",
+	"string": "string",
+	"int":    "int",
+}
+
+// helper is the flag.go helper of the flag styles.
+var helper = map[string]string{
+	"bool":   "boolFlag",
+	"boolno": "boolNoFlag",
+	"string": "stringFlag",
+	"int":    "intFlag",
+}
+
+type generator struct {
+	buf   bytes.Buffer
+	verbs map[string][]string
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) doc(doc string) {
+	if doc != "" {
+		g.printf("// %s\n", doc)
+	}
+}
+
+func (g *generator) constBlock(typ string, consts []constant) {
+	g.printf("const (\n")
+	for i, c := range consts {
+		if i > 0 {
+			g.printf("\n")
+		}
+		if c.doc != "" {
+			g.printf("// %s %s\n", c.name, c.doc)
+		}
+		g.printf("%s %s = %s\n", c.name, typ, c.value)
+	}
+	g.printf(")\n\n")
+}
+
+func (g *generator) flag(e *entry) error {
+	typ, name, style := e.get("type"), e.get("name"), e.get("style")
+	if typ == "" || name == "" {
+		return fmt.Errorf("line %d: flag needs type and name", e.line)
+	}
+	under, ok := goType[style]
+	if !ok {
+		return fmt.Errorf("line %d: unknown flag style %q", e.line, style)
+	}
+	verbs := e.fields("verb")
+	if len(verbs) == 0 {
+		return fmt.Errorf("line %d: flag %s applies to no verb", e.line, name)
+	}
+	consts, err := e.consts()
+	if err != nil {
+		return err
+	}
+
+	g.doc(e.get("doc"))
+	g.printf("type %s %s\n\n", typ, under)
+	arg := "-" + name
+	if style == "boolno" {
+		arg += " or -no" + name
+	}
+	for _, verb := range verbs {
+		g.printf("// %s returns the hdiutil %s %s argument of x.\n", methodName(verb), verb, arg)
+		g.printf("func (x %s) %s() []string { return %s(%q, %s(x)) }\n\n", typ, methodName(verb), helper[style], name, under)
+	}
+	if len(consts) > 0 {
+		g.constBlock(typ, consts)
+	}
+	return nil
+}
+
+func (g *generator) enum(e *entry) error {
+	typ := e.get("type")
+	if typ == "" {
+		return fmt.Errorf("line %d: enum needs a type", e.line)
+	}
+	consts, err := e.consts()
+	if err != nil {
+		return err
+	}
+
+	g.doc(e.get("doc"))
+	g.printf("type %s string\n\n", typ)
+	g.constBlock(typ, consts)
+	g.printf("// String returns x as given to hdiutil.\n")
+	g.printf("func (x %s) String() string { return string(x) }\n\n", typ)
+
+	table := strings.ToLower(typ[:1]) + typ[1:] + "s"
+	g.printf("// %s is the %s values known to hdiutil.\n", table, typ)
+	g.printf("var %s = map[%s]bool{\n", table, typ)
+	for _, c := range consts {
+		g.printf("%s: true,\n", c.name)
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+func (g *generator) verb(e *entry) error {
+	name := e.get("name")
+	if name == "" {
+		return fmt.Errorf("line %d: verb needs a name", e.line)
+	}
+	g.verbs[name] = append(g.verbs[name], e.fields("accepts")...)
+	return nil
+}
+
+func (g *generator) verbTable() {
+	names := make([]string, 0, len(g.verbs))
+	for name := range g.verbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.printf("// verbFlags is the command-line flags accepted by each hdiutil verb, without the leading dash.\n")
+	g.printf("var verbFlags = map[string]map[string]bool{\n")
+	for _, name := range names {
+		flags := append([]string(nil), g.verbs[name]...)
+		sort.Strings(flags)
+		g.printf("%q: {\n", name)
+		for i, f := range flags {
+			if i > 0 && f == flags[i-1] {
+				continue
+			}
+			g.printf("%q: true,\n", f)
+		}
+		g.printf("},\n")
+	}
+	g.printf("}\n")
+}
+
+func generate(entries []*entry) ([]byte, error) {
+	g := &generator{verbs: make(map[string][]string)}
+	g.printf("// Code generated by flaggen from flags.yaml. DO NOT EDIT.\n\n")
+	g.printf("package hdiutil\n\n")
+
+	for _, e := range entries {
+		var err error
+		switch kind := e.get("kind"); kind {
+		case "flag":
+			err = g.flag(e)
+		case "enum":
+			err = g.enum(e)
+		case "verb":
+			err = g.verb(e)
+		default:
+			err = fmt.Errorf("line %d: unknown kind %q", e.line, kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	g.verbTable()
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, g.buf.Bytes())
+	}
+	return src, nil
+}
+
+func main() {
+	in := flag.String("in", "flags.yaml", "flag spec `file`")
+	out := flag.String("out", "flags_gen.go", "generated Go `file`")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("flaggen: ")
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries, err := parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+
+	src, err := generate(entries)
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
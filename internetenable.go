@@ -0,0 +1,96 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InternetEnableMode specify the operation of InternetEnable.
+type InternetEnableMode int
+
+const (
+	// InternetEnableQuery reports whether the image is internet-enabled, without changing it.
+	InternetEnableQuery InternetEnableMode = iota
+	// InternetEnableYes enables the internet-enable flag of the image.
+	InternetEnableYes
+	// InternetEnableNo disables the internet-enable flag of the image.
+	InternetEnableNo
+)
+
+func (m InternetEnableMode) String() string {
+	switch m {
+	case InternetEnableQuery:
+		return "query"
+	case InternetEnableYes:
+		return "yes"
+	case InternetEnableNo:
+		return "no"
+	}
+	return "InternetEnableMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// InternetEnable enable, disable or query the internet-enable flag of a UDIF image.
+// An internet-enabled image is copied out and moved to the trash by the Finder once downloaded.
+// The returns internet-enable state of image after the operation and error.
+//
+// The flag is ignored since macOS 10.15, which no longer supports the verb; it is kept for legacy distribution tools.
+func InternetEnable(image string, mode InternetEnableMode) (bool, error) {
+	return DefaultClient.InternetEnable(image, mode)
+}
+
+// InternetEnable is like the package-level InternetEnable, but runs hdiutil with the configuration of c.
+func (c *Client) InternetEnable(image string, mode InternetEnableMode) (bool, error) {
+	switch mode {
+	case InternetEnableQuery, InternetEnableYes, InternetEnableNo:
+	default:
+		return false, fmt.Errorf("invalid internet-enable mode %v", mode)
+	}
+
+	cmd := c.command("internet-enable", "-"+mode.String(), image)
+	cmd.target = image
+	if mode != InternetEnableQuery {
+		cmd.output = image
+	}
+
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return false, err
+	}
+
+	if mode != InternetEnableQuery {
+		return mode == InternetEnableYes, nil
+	}
+	enabled, ok := parseInternetEnable(out)
+	if !ok {
+		return false, fmt.Errorf("no state in hdiutil internet-enable output: %s", out)
+	}
+
+	return enabled, nil
+}
+
+// parseInternetEnable parses the hdiutil internet-enable -query output, such as
+//
+//	internet-enable: YES
+func parseInternetEnable(out []byte) (enabled, ok bool) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(strings.ToLower(sc.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[len(fields)-1] {
+		case "yes", "true":
+			enabled, ok = true, true
+		case "no", "false":
+			enabled, ok = false, true
+		}
+	}
+	return enabled, ok
+}
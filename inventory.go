@@ -0,0 +1,153 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// inventoryChecksum is the checksum type recorded by Inventory.
+const inventoryChecksum = ChecksumSHA256
+
+// imageExts is the file name extensions of the disk images found by Inventory.
+var imageExts = map[string]bool{
+	".cdr":          true,
+	".dmg":          true,
+	".img":          true,
+	".iso":          true,
+	".smi":          true,
+	".sparsebundle": true,
+	".sparseimage":  true,
+}
+
+// ImageRecord is the manifest entry of a disk image.
+type ImageRecord struct {
+	// Path is the slash-separated path of the image, relative to the inventoried directory.
+	Path string `json:"path"`
+
+	// Format is the image format, such as UDZO.
+	Format string `json:"format,omitempty"`
+
+	// Size is the size of the image on disk in bytes, including all the bands of a sparse bundle.
+	Size int64 `json:"size"`
+
+	// ChecksumType and Checksum is the checksum of the image data.
+	ChecksumType ChecksumType `json:"checksum_type,omitempty"`
+	Checksum     string       `json:"checksum,omitempty"`
+
+	// Encrypted reports whether the image is encrypted.
+	Encrypted bool `json:"encrypted"`
+
+	// Error is the error which prevented the image from being inspected, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Manifest is the JSON manifest of a directory of disk images.
+type Manifest struct {
+	Created time.Time     `json:"created"`
+	Images  []ImageRecord `json:"images"`
+}
+
+// Inventory walks the dir directory tree and returns the record of every disk image found, identified by file name extension.
+//
+// Each image is inspected with imageinfo and checksummed with checksum.
+// An image which can not be inspected, such as an encrypted image, does not stop the walk; its record has the Error field set.
+func Inventory(dir string) ([]ImageRecord, error) {
+	return DefaultClient.Inventory(dir)
+}
+
+// Inventory is like the package-level Inventory, but runs hdiutil with the configuration of c.
+func (c *Client) Inventory(dir string) ([]ImageRecord, error) {
+	var records []ImageRecord
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !imageExts[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rec := c.inventoryImage(path, info)
+		rec.Path = filepath.ToSlash(rel)
+		records = append(records, rec)
+
+		if info.IsDir() {
+			// a sparse bundle is inventoried as a whole.
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// inventoryImage returns the record of the image at path, without its Path.
+func (c *Client) inventoryImage(path string, fi os.FileInfo) ImageRecord {
+	var rec ImageRecord
+
+	size, err := diskSize(path, fi)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	rec.Size = size
+
+	info, err := c.ImageInfo(path)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	rec.Format = info.Format
+	rec.Encrypted = info.Properties.Encrypted
+
+	sum, err := c.Checksum(path, inventoryChecksum)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	rec.ChecksumType = inventoryChecksum
+	rec.Checksum = sum.Hex
+
+	return rec
+}
+
+// diskSize returns the size of the file at path, or the total size of the files under path if it is a directory.
+func diskSize(path string, fi os.FileInfo) (int64, error) {
+	if !fi.IsDir() {
+		return fi.Size(), nil
+	}
+	var total int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// WriteManifest writes the JSON manifest of records to w.
+//
+// The image paths are relative to the inventoried directory, so the manifest should be stored at its root for VerifyManifest.
+func WriteManifest(w io.Writer, records []ImageRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(Manifest{Created: time.Now().UTC(), Images: records})
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "os/exec"
+
+// EncryptionInfo is the encryption information of a disk image reported by hdiutil isencrypted.
+type EncryptionInfo struct {
+	// Encrypted reports whether the image is encrypted. The other fields are only set if it is.
+	Encrypted bool `plist:"encrypted"`
+
+	// UUID is the UUID of the encrypted image.
+	UUID string `plist:"uuid"`
+
+	// BlockSize is the size of the encrypted blocks, in bytes.
+	BlockSize int `plist:"blocksize"`
+
+	// PassphraseCount, PublicKeyCount and PrivateKeyCount is the number of passphrases, public keys and private keys which can unlock the image.
+	PassphraseCount int `plist:"passphrase-count"`
+	PublicKeyCount  int `plist:"public-key-count"`
+	PrivateKeyCount int `plist:"private-key-count"`
+
+	// MaxKeyCount is the maximum number of keys of the image.
+	MaxKeyCount int `plist:"max-key-count"`
+
+	// InKeychain reports whether a passphrase of the image is stored in the keychains of the current user,
+	// so that attach unlocks it without prompting. It is set by IsEncrypted, not decoded from the output.
+	InKeychain bool `plist:"-"`
+
+	// Raw is the property list output of hdiutil isencrypted.
+	Raw []byte `plist:"-"`
+}
+
+// KeyProtection is a kind of key which can unlock an encrypted image.
+type KeyProtection string
+
+const (
+	// ProtectionPassphrase is a passphrase, given with Passphrase or stored in the keychain.
+	ProtectionPassphrase KeyProtection = "passphrase"
+
+	// ProtectionPublicKey is a certificate whose private key is looked up in the keychains, such as given to create with -certificate.
+	ProtectionPublicKey KeyProtection = "public-key"
+
+	// ProtectionPrivateKey is a private key, such as given with Recover.
+	ProtectionPrivateKey KeyProtection = "private-key"
+)
+
+// Protections returns the kinds of keys which can unlock the image.
+func (i EncryptionInfo) Protections() []KeyProtection {
+	var p []KeyProtection
+	if i.PassphraseCount > 0 {
+		p = append(p, ProtectionPassphrase)
+	}
+	if i.PublicKeyCount > 0 {
+		p = append(p, ProtectionPublicKey)
+	}
+	if i.PrivateKeyCount > 0 {
+		p = append(p, ProtectionPrivateKey)
+	}
+	return p
+}
+
+// NonInteractive reports whether the image can be attached without prompting for a key:
+// it is not encrypted, its passphrase is in the keychain, or havePassphrase reports that the caller provides one with Passphrase.
+// The public key protection is not considered, as whether the keychains hold the matching private key is not known.
+func (i EncryptionInfo) NonInteractive(havePassphrase bool) bool {
+	return !i.Encrypted || i.InKeychain || havePassphrase && i.PassphraseCount > 0
+}
+
+// IsencryptedFlag is a hdiutil isencrypted command flag, returning its command-line arguments.
+type IsencryptedFlag interface {
+	IsencryptedFlag() []string
+}
+
+// IsEncrypted reports whether image is encrypted, and how it can be unlocked, without attaching it.
+// The keychains of the current user are searched for the passphrase of an encrypted image with security(1).
+func IsEncrypted(image string, flags ...IsencryptedFlag) (EncryptionInfo, error) {
+	return DefaultClient.IsEncrypted(image, flags...)
+}
+
+// IsEncrypted is like the package-level IsEncrypted, but runs hdiutil with the configuration of c.
+func (c *Client) IsEncrypted(image string, flags ...IsencryptedFlag) (EncryptionInfo, error) {
+	cmd := c.plistCommand("isencrypted")
+	cmd.target = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.IsencryptedFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	out, err := c.runPlist(cmd)
+	if err != nil {
+		return EncryptionInfo{}, err
+	}
+
+	info, err := ParseIsEncryptedPlist(out)
+	if err != nil {
+		return EncryptionInfo{}, err
+	}
+	if info.Encrypted && info.UUID != "" && info.PassphraseCount > 0 {
+		info.InKeychain = keychainHasPassphrase(info.UUID)
+	}
+
+	return info, nil
+}
+
+// keychainHasPassphrase reports whether the keychains hold the disk image password of the encrypted image uuid,
+// which the DiskImages framework stores with the image UUID as account.
+// The search does not unlock the keychain nor read the password, so it never prompts.
+func keychainHasPassphrase(uuid string) bool {
+	return exec.Command("security", "find-generic-password", "-D", "disk image password", "-a", uuid).Run() == nil
+}
+
+// ParseIsEncryptedPlist decodes the hdiutil isencrypted -plist output data.
+func ParseIsEncryptedPlist(data []byte) (EncryptionInfo, error) {
+	var info EncryptionInfo
+	if err := unmarshalPlist(data, &info); err != nil {
+		return EncryptionInfo{}, err
+	}
+	info.Raw = rawPlist(data)
+	return info, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ToISO converts the disk image dmg to a CD/DVD master (UDTO) at iso, and checks the result can be mounted.
+//
+// hdiutil names UDTO images with a ".cdr" extension; ToISO writes iso under the exact given name instead.
+// The result is a raw copy of the image blocks, so it only contains an ISO 9660 filesystem if dmg does;
+// use Makehybrid to build an ISO 9660 or hybrid image from a folder.
+// flags are passed to convert. iso is removed if it can not be mounted.
+func ToISO(dmg, iso string, flags ...ConvertFlag) error {
+	return DefaultClient.ToISO(dmg, iso, flags...)
+}
+
+// ToISO is like the package-level ToISO, but runs hdiutil with the configuration of c.
+func (c *Client) ToISO(dmg, iso string, flags ...ConvertFlag) error {
+	// convert next to iso, so the result can be renamed into place.
+	tmp := filepath.Join(filepath.Dir(iso), "."+strings.TrimSuffix(filepath.Base(iso), filepath.Ext(iso))+"-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	result, err := c.Convert(dmg, ConvertUDTO, tmp, flags...)
+	if err != nil {
+		return fmt.Errorf("convert %s: %w", dmg, err)
+	}
+	if len(result.Outputs) != 1 {
+		for _, out := range result.Outputs {
+			os.Remove(out)
+		}
+		return fmt.Errorf("convert %s: %d output files, want 1", dmg, len(result.Outputs))
+	}
+	if err := os.Rename(result.Outputs[0], iso); err != nil {
+		os.Remove(result.Outputs[0])
+		return err
+	}
+
+	if err := c.checkMountable(iso); err != nil {
+		os.Remove(iso)
+		return err
+	}
+
+	return nil
+}
+
+// checkMountable attaches image read-only to a private mount point and detaches it again.
+func (c *Client) checkMountable(image string) error {
+	mountRoot, err := os.MkdirTemp(c.tempDir(), "hdiutil-check")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(mountRoot)
+
+	results, err := c.AttachAll([]string{image}, AttachReadonly, AttachNoBrowse, AttachNoAutoOpen, AttachMountRandom(mountRoot))
+	if err != nil {
+		return fmt.Errorf("%s is not mountable: %w", image, err)
+	}
+	dev := results[0].DeviceNode().String()
+	mounted := len(results[0].MountPoints()) > 0
+
+	if err := c.Detach(dev, internalCall()); err != nil {
+		if err := c.Detach(dev, DetachForce, internalCall()); err != nil {
+			return fmt.Errorf("detach %s: %w", dev, err)
+		}
+	}
+	if !mounted {
+		return fmt.Errorf("%s is not mountable: no mountable file systems", image)
+	}
+
+	return nil
+}
+
+// FromISO converts the ISO image iso to a disk image at dmg in format, UDZO if zero, and verifies the checksum of the result.
+//
+// A hybrid ISO image, such as a bootable installer with an Apple or GUID partition map besides the ISO 9660 filesystem,
+// keeps its partition map: ConvertPmap is ignored for it, and FromISO fails if the result is no longer partitioned.
+// flags are passed to convert. dmg is removed if the verification fails.
+func FromISO(iso, dmg string, format Format, flags ...ConvertFlag) error {
+	return DefaultClient.FromISO(iso, dmg, format, flags...)
+}
+
+// FromISO is like the package-level FromISO, but runs hdiutil with the configuration of c.
+func (c *Client) FromISO(iso, dmg string, format Format, flags ...ConvertFlag) error {
+	if format == 0 {
+		format = ConvertUDZO
+	}
+
+	info, err := c.ImageInfo(iso)
+	if err != nil {
+		return fmt.Errorf("imageinfo %s: %w", iso, err)
+	}
+	hybrid := info.Properties.Partitioned
+	if hybrid {
+		kept := flags[:0:0]
+		for _, flag := range flags {
+			if flag != ConvertPmap {
+				kept = append(kept, flag)
+			}
+		}
+		flags = kept
+	}
+
+	if _, err := c.Convert(iso, format, dmg, flags...); err != nil {
+		return fmt.Errorf("convert %s: %w", iso, err)
+	}
+
+	if _, err := c.Verify(dmg); err != nil {
+		os.Remove(dmg)
+		return fmt.Errorf("verify %s: %w", dmg, err)
+	}
+	if hybrid {
+		info, err := c.ImageInfo(dmg)
+		if err != nil {
+			return fmt.Errorf("imageinfo %s: %w", dmg, err)
+		}
+		if !info.Properties.Partitioned {
+			os.Remove(dmg)
+			return fmt.Errorf("%s lost the partition map of the hybrid image %s", dmg, iso)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxLogArgs is the maximum length of the argv logged for an invocation.
+const maxLogArgs = 256
+
+// maxLogStderr is the maximum length of the standard error logged for a failed invocation.
+const maxLogStderr = 1024
+
+// WithLogger logs every hdiutil invocation of the Client to l.
+//
+// Each invocation is logged as one record with its verb, truncated argv, duration and exit status, and the truncated
+// standard error of a failed invocation, at the levels set by WithLogLevels. The secrets of the argv, such as the password
+// of an image URL, are redacted; a Passphrase is never logged, as it is given on the standard input.
+// When Verbose or Debug is given, the hdiutil output lines are also logged at slog.LevelDebug.
+// The invocations of the helper tools, such as diskutil, are logged the same way, with the tool name as verb.
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithLogLevels sets the levels the invocations are logged at by WithLogger: success for the successful invocations and failure for the failed ones.
+// The defaults are slog.LevelInfo and slog.LevelError.
+func WithLogLevels(success, failure slog.Level) ClientOption {
+	return func(c *Client) {
+		c.logLevel = success
+		c.errorLevel = failure
+	}
+}
+
+// logInvocation logs the finished invocation cmd to the Client logger.
+func (c *Client) logInvocation(cmd *command, start time.Time, stdout, stderr []byte, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if cmd.verbose() && cmd.progress == nil {
+		c.logOutput(ctx, cmd.verb, stdout)
+		c.logOutput(ctx, cmd.verb, stderr)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("verb", cmd.verb),
+		slog.String("args", truncate(strings.Join(redactArgs(cmd.args), " "), maxLogArgs)),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("exit_status", exitStatus(err)),
+	}
+	level := c.logLevel
+	if err != nil {
+		level = c.errorLevel
+		// the standard error is logged on its own, so log the cause of an *Error without it.
+		msg := err.Error()
+		var herr *Error
+		if errors.As(err, &herr) {
+			msg = herr.Err.Error()
+		}
+		attrs = append(attrs, slog.String("error", msg))
+		if s := strings.TrimSpace(string(stderr)); s != "" {
+			attrs = append(attrs, slog.String("stderr", truncate(s, maxLogStderr)))
+		}
+	}
+	msg := "hdiutil"
+	if cmd.tool {
+		msg = cmd.verb
+	}
+	c.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logOutput logs each line of the hdiutil output out at slog.LevelDebug.
+func (c *Client) logOutput(ctx context.Context, verb string, out []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			c.logger.LogAttrs(ctx, slog.LevelDebug, "hdiutil output", slog.String("verb", verb), slog.String("line", line))
+		}
+	}
+}
+
+// secretImageKeys is the substrings of the -imagekey keys whose values are redacted from the logs.
+var secretImageKeys = []string{"pass", "secret", "token"}
+
+// redactArgs returns a copy of args with the secrets redacted: the password of the URLs, such as of an image attached over HTTP,
+// and the values of the image keys named like a secret.
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		if strings.Contains(arg, "://") {
+			if u, err := url.Parse(arg); err == nil && u.User != nil {
+				redacted[i] = u.Redacted()
+			}
+			continue
+		}
+		if i == 0 || !strings.HasSuffix(args[i-1], "imagekey") {
+			continue
+		}
+		if key, _, ok := strings.Cut(arg, "="); ok {
+			for _, s := range secretImageKeys {
+				if strings.Contains(strings.ToLower(key), s) {
+					redacted[i] = key + "=xxxxx"
+				}
+			}
+		}
+	}
+	return redacted
+}
+
+// verbose reports whether cmd is run with Verbose or Debug.
+func (cmd *command) verbose() bool {
+	for _, arg := range cmd.args {
+		if arg == "-verbose" || arg == "-debug" {
+			return true
+		}
+	}
+	return false
+}
+
+// exitStatus returns the process exit status of the invocation error err, 0 if err is nil, or -1 if the process did not exit normally.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee interface{ ExitCode() int }
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
+
+// truncate returns s truncated to n bytes, marking the truncation with "...".
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
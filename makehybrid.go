@@ -4,190 +4,211 @@
 
 package hdiutil
 
-import "os/exec"
-
-// makehybridFlag implements a hdiutil makehybrid command flag interface.
-type makehybridFlag interface {
-	makehybridFlag() []string
+// MakehybridFlag is a hdiutil makehybrid command flag, returning its command-line arguments.
+type MakehybridFlag interface {
+	MakehybridFlag() []string
 }
 
 type makehybridHFS bool
 
-func (m makehybridHFS) makehybridFlag() []string { return boolFlag("hfs", bool(m)) }
+func (m makehybridHFS) MakehybridFlag() []string { return boolFlag("hfs", bool(m)) }
 
 type makehybridISO bool
 
-func (m makehybridISO) makehybridFlag() []string { return boolFlag("iso", bool(m)) }
+func (m makehybridISO) MakehybridFlag() []string { return boolFlag("iso", bool(m)) }
 
 type makehybridJoliet bool
 
-func (m makehybridJoliet) makehybridFlag() []string { return boolFlag("joliet", bool(m)) }
+func (m makehybridJoliet) MakehybridFlag() []string { return boolFlag("joliet", bool(m)) }
 
 type makehybridUDF bool
 
-func (m makehybridUDF) makehybridFlag() []string { return boolFlag("udf", bool(m)) }
+func (m makehybridUDF) MakehybridFlag() []string { return boolFlag("udf", bool(m)) }
 
-type makehybridHFSBlessedDirectory bool
+// MakehybridHFSBlessedDirectory path to directory which should be "blessed" for OS X booting on the generated filesystem.
+//
+// This assumes the directory has been otherwise prepared, for example with bless -bootinfo to create a valid BootX file (see Bless). (HFS+ only).
+type MakehybridHFSBlessedDirectory string
 
-func (m makehybridHFSBlessedDirectory) makehybridFlag() []string {
-	return boolFlag("hfs-blessed-directory", bool(m))
+func (m MakehybridHFSBlessedDirectory) MakehybridFlag() []string {
+	return stringFlag("hfs-blessed-directory", string(m))
 }
 
-type makehybridHFSOpenfolder bool
+// MakehybridHFSOpenfolder path to a directory that will be opened by the Finder automatically.  See also the -openfolder option in bless(8) (HFS+ only).
+type MakehybridHFSOpenfolder string
 
-func (m makehybridHFSOpenfolder) makehybridFlag() []string { return boolFlag("hfs-openfolder", bool(m)) }
+func (m MakehybridHFSOpenfolder) MakehybridFlag() []string {
+	return stringFlag("hfs-openfolder", string(m))
+}
 
 type makehybridHFSStartupfileSize bool
 
-func (m makehybridHFSStartupfileSize) makehybridFlag() []string {
+func (m makehybridHFSStartupfileSize) MakehybridFlag() []string {
 	return boolFlag("hfs-startupfile-size", bool(m))
 }
 
 type makehybridAbstractFile bool
 
-func (m makehybridAbstractFile) makehybridFlag() []string { return boolFlag("abstract-file", bool(m)) }
+func (m makehybridAbstractFile) MakehybridFlag() []string { return boolFlag("abstract-file", bool(m)) }
 
 type makehybridBibliographyFile bool
 
-func (m makehybridBibliographyFile) makehybridFlag() []string {
+func (m makehybridBibliographyFile) MakehybridFlag() []string {
 	return boolFlag("bibliography-file", bool(m))
 }
 
 type makehybridCopyrightFile bool
 
-func (m makehybridCopyrightFile) makehybridFlag() []string { return boolFlag("copyright-file", bool(m)) }
+func (m makehybridCopyrightFile) MakehybridFlag() []string { return boolFlag("copyright-file", bool(m)) }
 
 type makehybridApplication bool
 
-func (m makehybridApplication) makehybridFlag() []string { return boolFlag("application", bool(m)) }
+func (m makehybridApplication) MakehybridFlag() []string { return boolFlag("application", bool(m)) }
 
 type makehybridPreparer bool
 
-func (m makehybridPreparer) makehybridFlag() []string { return boolFlag("preparer", bool(m)) }
+func (m makehybridPreparer) MakehybridFlag() []string { return boolFlag("preparer", bool(m)) }
 
 type makehybridPublisher bool
 
-func (m makehybridPublisher) makehybridFlag() []string { return boolFlag("publisher", bool(m)) }
+func (m makehybridPublisher) MakehybridFlag() []string { return boolFlag("publisher", bool(m)) }
 
 type makehybridSystemID bool
 
-func (m makehybridSystemID) makehybridFlag() []string { return boolFlag("system-id", bool(m)) }
+func (m makehybridSystemID) MakehybridFlag() []string { return boolFlag("system-id", bool(m)) }
 
 type makehybridKeepMacSpecific bool
 
-func (m makehybridKeepMacSpecific) makehybridFlag() []string {
+func (m makehybridKeepMacSpecific) MakehybridFlag() []string {
 	return boolFlag("keep-mac-specific", bool(m))
 }
 
 type makehybridEltoritoBoot bool
 
-func (m makehybridEltoritoBoot) makehybridFlag() []string { return boolFlag("eltorito-boot", bool(m)) }
+func (m makehybridEltoritoBoot) MakehybridFlag() []string { return boolFlag("eltorito-boot", bool(m)) }
 
 type makehybridHardDiskBoot bool
 
-func (m makehybridHardDiskBoot) makehybridFlag() []string { return boolFlag("hard-disk-boot", bool(m)) }
+func (m makehybridHardDiskBoot) MakehybridFlag() []string { return boolFlag("hard-disk-boot", bool(m)) }
 
 type makehybridNoEmulBoot bool
 
-func (m makehybridNoEmulBoot) makehybridFlag() []string { return boolFlag("no-emul-boot", bool(m)) }
+func (m makehybridNoEmulBoot) MakehybridFlag() []string { return boolFlag("no-emul-boot", bool(m)) }
 
 type makehybridNoBoot bool
 
-func (m makehybridNoBoot) makehybridFlag() []string { return boolFlag("no-boot", bool(m)) }
+func (m makehybridNoBoot) MakehybridFlag() []string { return boolFlag("no-boot", bool(m)) }
 
 type makehybridBootLoadSeg bool
 
-func (m makehybridBootLoadSeg) makehybridFlag() []string { return boolFlag("boot-load-seg", bool(m)) }
+func (m makehybridBootLoadSeg) MakehybridFlag() []string { return boolFlag("boot-load-seg", bool(m)) }
 
 type makehybridBootLoadSize bool
 
-func (m makehybridBootLoadSize) makehybridFlag() []string { return boolFlag("boot-load-seg", bool(m)) }
+func (m makehybridBootLoadSize) MakehybridFlag() []string { return boolFlag("boot-load-seg", bool(m)) }
 
 type makehybridEltoritoPlatform bool
 
-func (m makehybridEltoritoPlatform) makehybridFlag() []string {
+func (m makehybridEltoritoPlatform) MakehybridFlag() []string {
 	return boolFlag("eltorito-platform", bool(m))
 }
 
 type makehybridEltoritoSpecification bool
 
-func (m makehybridEltoritoSpecification) makehybridFlag() []string {
+func (m makehybridEltoritoSpecification) MakehybridFlag() []string {
 	return boolFlag("eltorito-specification", bool(m))
 }
 
 type makehybridUDFVersion bool
 
-func (m makehybridUDFVersion) makehybridFlag() []string { return boolFlag("udf-version", bool(m)) }
+func (m makehybridUDFVersion) MakehybridFlag() []string { return boolFlag("udf-version", bool(m)) }
 
 type makehybridDefaultVolumeName bool
 
-func (m makehybridDefaultVolumeName) makehybridFlag() []string {
+func (m makehybridDefaultVolumeName) MakehybridFlag() []string {
 	return boolFlag("default-volume-name", bool(m))
 }
 
 type makehybridHFSVolumeName bool
 
-func (m makehybridHFSVolumeName) makehybridFlag() []string {
+func (m makehybridHFSVolumeName) MakehybridFlag() []string {
 	return boolFlag("hfs-volume-name", bool(m))
 }
 
 type makehybridISOVolumeName bool
 
-func (m makehybridISOVolumeName) makehybridFlag() []string {
+func (m makehybridISOVolumeName) MakehybridFlag() []string {
 	return boolFlag("iso-volume-name", bool(m))
 }
 
 type makehybridJolietVolumeName bool
 
-func (m makehybridJolietVolumeName) makehybridFlag() []string {
+func (m makehybridJolietVolumeName) MakehybridFlag() []string {
 	return boolFlag("joliet-volume-name", bool(m))
 }
 
 type makehybridUDFVolumeName bool
 
-func (m makehybridUDFVolumeName) makehybridFlag() []string {
+func (m makehybridUDFVolumeName) MakehybridFlag() []string {
 	return boolFlag("udf-volume-name", bool(m))
 }
 
-type makehybridHideAll bool
+// MakehybridHideAll a glob expression of files and directories that should not be exposed in the generated filesystems.
+//
+// The string is passed to glob(3) for evaluation as is, so it must not be quoted for a shell.
+// Although this option can not be used multiple times, an arbitrarily complex glob expression can be used.
+// Glob composes such an expression from several patterns.
+type MakehybridHideAll string
 
-func (m makehybridHideAll) makehybridFlag() []string { return boolFlag("hide-all", bool(m)) }
+func (m MakehybridHideAll) MakehybridFlag() []string { return stringFlag("hide-all", string(m)) }
 
-type makehybridHideHFS bool
+// MakehybridHideHFS a glob expression of files and directories that should not be exposed via the HFS+ filesystem, although the data may still be present for use by other filesystems (HFS+ only).
+type MakehybridHideHFS string
 
-func (m makehybridHideHFS) makehybridFlag() []string { return boolFlag("hide-hfs", bool(m)) }
+func (m MakehybridHideHFS) MakehybridFlag() []string { return stringFlag("hide-hfs", string(m)) }
 
-type makehybridHideISO bool
+// MakehybridHideISO a glob expression of files and directories that should not be exposed via the ISO filesystem, although the data may still be present for use by other filesystems (ISO9660 only).
+//
+// Per above, the Joliet hierarchy will supersede the ISO hierarchy when the hybrid is mounted as an ISO 9660 filesystem on OS X.
+// Therefore, if Joliet is being generated (the default) -hide-joliet will also be needed to hide the file from mount_cd9660(8).
+type MakehybridHideISO string
 
-func (m makehybridHideISO) makehybridFlag() []string { return boolFlag("hide-iso", bool(m)) }
+func (m MakehybridHideISO) MakehybridFlag() []string { return stringFlag("hide-iso", string(m)) }
 
-type makehybridHideJoliet bool
+// MakehybridHideJoliet a glob expression of files and directories that should not be exposed via the Joliet filesystem, although the data may still be present for use by other filesystems (Joliet only).
+//
+// Because OS X's ISO 9660 filesystem uses the Joliet catalog if it is available, -hide-joliet effectively supersedes -hide-iso when the resulting filesystem is mounted as ISO on OS X.
+type MakehybridHideJoliet string
 
-func (m makehybridHideJoliet) makehybridFlag() []string { return boolFlag("hide-joliet", bool(m)) }
+func (m MakehybridHideJoliet) MakehybridFlag() []string { return stringFlag("hide-joliet", string(m)) }
 
-type makehybridHideUDF bool
+// MakehybridHideUDF a glob expression of files and directories that should not be exposed via the UDF filesystem, although the data may still be present for use by other filesystems (UDF only).
+type MakehybridHideUDF string
 
-func (m makehybridHideUDF) makehybridFlag() []string { return boolFlag("hide-udf", bool(m)) }
+func (m MakehybridHideUDF) MakehybridFlag() []string { return stringFlag("hide-udf", string(m)) }
 
-type makehybridOnlyUDF bool
+// MakehybridOnlyUDF a glob expression of objects that should only be exposed in UDF.
+type MakehybridOnlyUDF string
 
-func (m makehybridOnlyUDF) makehybridFlag() []string { return boolFlag("only-udf", bool(m)) }
+func (m MakehybridOnlyUDF) MakehybridFlag() []string { return stringFlag("only-udf", string(m)) }
 
-type makehybridOnlyISO bool
+// MakehybridOnlyISO a glob expression of objects that should only be exposed in ISO.
+type MakehybridOnlyISO string
 
-func (m makehybridOnlyISO) makehybridFlag() []string { return boolFlag("only-iso", bool(m)) }
+func (m MakehybridOnlyISO) MakehybridFlag() []string { return stringFlag("only-iso", string(m)) }
 
-type makehybridOnlyJoliet bool
+// MakehybridOnlyJoliet a glob expression of objects that should only be exposed in Joliet.
+type MakehybridOnlyJoliet string
 
-func (m makehybridOnlyJoliet) makehybridFlag() []string { return boolFlag("only-joliet", bool(m)) }
+func (m MakehybridOnlyJoliet) MakehybridFlag() []string { return stringFlag("only-joliet", string(m)) }
 
 type makehybridPrintSize bool
 
-func (m makehybridPrintSize) makehybridFlag() []string { return boolFlag("print-size", bool(m)) }
+func (m makehybridPrintSize) MakehybridFlag() []string { return boolFlag("print-size", bool(m)) }
 
 type makehybridPlistin bool
 
-func (m makehybridPlistin) makehybridFlag() []string { return boolFlag("plistin", bool(m)) }
+func (m makehybridPlistin) MakehybridFlag() []string { return boolFlag("plistin", bool(m)) }
 
 const (
 	// MakehybridHFS generate an HFS+ filesystem.
@@ -218,14 +239,6 @@ const (
 	// UDF is the standard interchange format for DVDs, although operating system support varies based on OS version and UDF version.
 	MakeHybridUDF makehybridUDF = true
 
-	// MakehybridHFSBlessedDirectory path to directory which should be "blessed" for OS X booting on the generated filesystem.
-	//
-	// This assumes the directory has been otherwise prepared, for example with bless -bootinfo to create a valid BootX file. (HFS+ only).
-	MakehybridHFSBlessedDirectory makehybridHFSBlessedDirectory = true
-
-	// MakehybridHFSOpenfolder path to a directory that will be opened by the Finder automatically.  See also the -openfolder option in bless(8) (HFS+ only).
-	MakehybridHFSOpenfolder makehybridHFSOpenfolder = true
-
 	// MakehybridHFSStartupfileSize allocate an empty HFS+ Startup File of the specified size, in bytes (HFS+ only).
 	MakehybridHFSStartupfileSize makehybridHFSStartupfileSize = true
 
@@ -301,38 +314,6 @@ const (
 	// MakehybridUDFVolumeName volume name for just the UDF filesystem if it should be different (UDF only).
 	MakehybridUDFVolumeName makehybridUDFVolumeName = true
 
-	// MakehybridHideAll a glob expression of files and directories that should not be exposed in the generated filesystems.
-	//
-	// The string may need to be quoted to avoid shell expansion, and will be passed to glob(3) for evaluation.
-	// Although this option can not be used multiple times, an arbitrarily complex glob expression can be used.
-	MakehybridHideAll makehybridHideAll = true
-
-	// MakehybridHideHFS a glob expression of files and directories that should not be exposed via the HFS+ filesystem, although the data may still be present for use by other filesystems (HFS+ only).
-	MakehybridHideHFS makehybridHideHFS = true
-
-	// MakehybridHideISO a glob expression of files and directories that should not be exposed via the ISO filesystem, although the data may still be present for use by other filesystems (ISO9660 only).
-	//
-	// Per above, the Joliet hierarchy will supersede the ISO hierarchy when the hybrid is mounted as an ISO 9660 filesystem on OS X.
-	// Therefore, if Joliet is being generated (the default) -hide-joliet will also be needed to hide the file from mount_cd9660(8).
-	MakehybridHideISO makehybridHideISO = true
-
-	// MakehybridHideJoliet a glob expression of files and directories that should not be exposed via the Joliet filesystem, although the data may still be present for use by other filesystems (Joliet only).
-	//
-	// Because OS X's ISO 9660 filesystem uses the Joliet catalog if it is available, -hide-joliet effectively supersedes -hide-iso when the resulting filesystem is mounted as ISO on OS X.
-	MakehybridHideJoliet makehybridHideJoliet = true
-
-	// MakehybridHideUDF a glob expression of files and directories that should not be exposed via the UDF filesystem, although the data may still be present for use by other filesystems (UDF only).
-	MakehybridHideUDF makehybridHideUDF = true
-
-	// MakehybridOnlyUDF a glob expression of objects that should only be exposed in UDF.
-	MakehybridOnlyUDF makehybridOnlyUDF = true
-
-	// MakehybridOnlyISO a glob expression of objects that should only be exposed in ISO.
-	MakehybridOnlyISO makehybridOnlyISO = true
-
-	// MakehybridOnlyJoliet a glob expression of objects that should only be exposed in Joleit.
-	MakehybridOnlyJoliet makehybridOnlyJoliet = true
-
 	// MakehybridPrintSize preflight the data and calculate an upper bound on the size of the image.  The actual size of the generated image is guaranteed to be less than or equal to this estimate.
 	MakehybridPrintSize makehybridPrintSize = true
 
@@ -340,19 +321,70 @@ const (
 	//
 	// Each command-line option should be a key in the dictionary, without the leading "-", and the value should be a string for path and string arguments, a number for number arguments, and a boolean for toggle options.
 	// The source argument should use a key of "source" and the image should use a key of "output".
+	//
+	// MakehybridWithSpec generates the plist from a MakehybridSpec and feeds it on standard input.
 	MakehybridPlistin makehybridPlistin = true
 )
 
 // Makehybrid generate a potentially-hybrid filesystem in a read-only disk image using the DiscRecording framework's content creation system.
-func Makehybrid(image, source string, flags ...makehybridFlag) error {
-	cmd := exec.Command(hdiutilPath, "makehybrid", image, source)
-	if len(flags) > 0 {
-		for _, flag := range flags {
-			cmd.Args = append(cmd.Args, flag.makehybridFlag()...)
+//
+// If MakehybridPreflight is given, the source names are checked with CheckHybridNames before hdiutil is run.
+func Makehybrid(image, source string, flags ...MakehybridFlag) error {
+	return DefaultClient.Makehybrid(image, source, flags...)
+}
+
+// Makehybrid is like the package-level Makehybrid, but runs hdiutil with the configuration of c.
+func (c *Client) Makehybrid(image, source string, flags ...MakehybridFlag) error {
+	cmd := c.command("makehybrid", image, source)
+	cmd.target = image
+	cmd.output = image
+	for _, flag := range flags {
+		if flag == MakehybridPreflight {
+			if err := CheckHybridNames(source, flags...); err != nil {
+				return err
+			}
+		}
+		cmd.flag(flag, flag.MakehybridFlag())
+	}
+
+	_, _, err := c.run(cmd)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MakehybridProgress is like Makehybrid, but streams the progress of the DiscRecording content creation to progress.
+//
+// Puppetstrings is added to flags. Add Verbose or Debug to flags to also receive the diagnostics lines as messages.
+func MakehybridProgress(image, source string, progress func(Progress), flags ...MakehybridFlag) error {
+	return DefaultClient.MakehybridProgress(image, source, progress, flags...)
+}
+
+// MakehybridProgress is like the package-level MakehybridProgress, but runs hdiutil with the configuration of c.
+func (c *Client) MakehybridProgress(image, source string, progress func(Progress), flags ...MakehybridFlag) error {
+	cmd := c.command("makehybrid", image, source)
+	cmd.target = image
+	cmd.output = image
+	cmd.args = append(cmd.args, Puppetstrings.MakehybridFlag()...)
+	for _, flag := range flags {
+		if flag == MakehybridPreflight {
+			if err := CheckHybridNames(source, flags...); err != nil {
+				return err
+			}
 		}
+		if flag == Puppetstrings {
+			continue
+		}
+		cmd.flag(flag, flag.MakehybridFlag())
+	}
+	cmd.progress = progress
+	if cmd.progress == nil {
+		cmd.progress = func(Progress) {}
 	}
 
-	err := cmd.Run()
+	_, _, err := c.run(cmd)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,134 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+)
+
+// MakehybridSpec specifies the parameters of a hybrid image generation, passed to hdiutil makehybrid -plistin as a property list.
+//
+// Each field corresponds to the makehybrid command-line option of the same name.
+// Unlike the command-line options, it can also express settings per El Torito boot image with EltoritoSpecification.
+type MakehybridSpec struct {
+	HFS    bool `plist:"hfs,omitempty"`
+	ISO    bool `plist:"iso,omitempty"`
+	Joliet bool `plist:"joliet,omitempty"`
+	UDF    bool `plist:"udf,omitempty"`
+
+	HFSBlessedDirectory string `plist:"hfs-blessed-directory,omitempty"`
+	HFSOpenfolder       string `plist:"hfs-openfolder,omitempty"`
+	HFSStartupfileSize  int64  `plist:"hfs-startupfile-size,omitempty"`
+
+	AbstractFile     string `plist:"abstract-file,omitempty"`
+	BibliographyFile string `plist:"bibliography-file,omitempty"`
+	CopyrightFile    string `plist:"copyright-file,omitempty"`
+	Application      string `plist:"application,omitempty"`
+	Preparer         string `plist:"preparer,omitempty"`
+	Publisher        string `plist:"publisher,omitempty"`
+	SystemID         string `plist:"system-id,omitempty"`
+	KeepMacSpecific  bool   `plist:"keep-mac-specific,omitempty"`
+
+	// EltoritoBootImage is the default El Torito boot image.
+	EltoritoBootImage
+
+	// EltoritoSpecification is the secondary non-default El Torito boot images.
+	EltoritoSpecification []EltoritoBootImage `plist:"eltorito-specification,omitempty"`
+
+	UDFVersion string `plist:"udf-version,omitempty"`
+
+	DefaultVolumeName string `plist:"default-volume-name,omitempty"`
+	HFSVolumeName     string `plist:"hfs-volume-name,omitempty"`
+	ISOVolumeName     string `plist:"iso-volume-name,omitempty"`
+	JolietVolumeName  string `plist:"joliet-volume-name,omitempty"`
+	UDFVolumeName     string `plist:"udf-volume-name,omitempty"`
+
+	HideAll    string `plist:"hide-all,omitempty"`
+	HideHFS    string `plist:"hide-hfs,omitempty"`
+	HideISO    string `plist:"hide-iso,omitempty"`
+	HideJoliet string `plist:"hide-joliet,omitempty"`
+	HideUDF    string `plist:"hide-udf,omitempty"`
+	OnlyUDF    string `plist:"only-udf,omitempty"`
+	OnlyISO    string `plist:"only-iso,omitempty"`
+	OnlyJoliet string `plist:"only-joliet,omitempty"`
+}
+
+// EltoritoBootImage specifies an El Torito boot image of a hybrid image.
+type EltoritoBootImage struct {
+	// EltoritoBoot is the path to the boot image within the source directory.
+	EltoritoBoot     string `plist:"eltorito-boot,omitempty"`
+	HardDiskBoot     bool   `plist:"hard-disk-boot,omitempty"`
+	NoEmulBoot       bool   `plist:"no-emul-boot,omitempty"`
+	NoBoot           bool   `plist:"no-boot,omitempty"`
+	BootLoadSeg      int    `plist:"boot-load-seg,omitempty"`
+	BootLoadSize     int    `plist:"boot-load-size,omitempty"`
+	EltoritoPlatform int    `plist:"eltorito-platform,omitempty"`
+}
+
+// makehybridPlist is the property list read by makehybrid -plistin.
+type makehybridPlist struct {
+	*MakehybridSpec
+	Source string `plist:"source"`
+	Output string `plist:"output"`
+}
+
+// MakehybridWithSpec is like Makehybrid, but passes the generation parameters of spec as a property list on standard input with -plistin.
+//
+// The source and output keys are filled from source and image.
+// flags should only control the hdiutil output, such as Verbose, since the generation parameters are read from spec.
+func MakehybridWithSpec(image, source string, spec *MakehybridSpec, flags ...MakehybridFlag) error {
+	return DefaultClient.MakehybridWithSpec(image, source, spec, flags...)
+}
+
+// MakehybridWithSpec is like the package-level MakehybridWithSpec, but runs hdiutil with the configuration of c.
+func (c *Client) MakehybridWithSpec(image, source string, spec *MakehybridSpec, flags ...MakehybridFlag) error {
+	if spec == nil {
+		spec = new(MakehybridSpec)
+	}
+	in, err := marshalPlist(makehybridPlist{MakehybridSpec: spec, Source: source, Output: image})
+	if err != nil {
+		return err
+	}
+
+	cmd := c.command("makehybrid", MakehybridPlistin.MakehybridFlag()...)
+	cmd.target = image
+	cmd.output = image
+	for _, flag := range flags {
+		if flag == MakehybridPlistin {
+			continue
+		}
+		if flag == MakehybridPreflight {
+			if err := CheckHybridNames(source, spec.flags()...); err != nil {
+				return err
+			}
+		}
+		cmd.flag(flag, flag.MakehybridFlag())
+	}
+	cmd.stdin = bytes.NewReader(in)
+
+	if _, _, err := c.run(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// flags returns the filesystem selection flags of s, used to preflight the source names.
+func (s *MakehybridSpec) flags() []MakehybridFlag {
+	var flags []MakehybridFlag
+	if s.HFS {
+		flags = append(flags, MakehybridHFS)
+	}
+	if s.ISO {
+		flags = append(flags, MakehybridISO)
+	}
+	if s.Joliet {
+		flags = append(flags, MakeHybridJoliet)
+	}
+	if s.UDF {
+		flags = append(flags, MakeHybridUDF)
+	}
+	return flags
+}
@@ -0,0 +1,165 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ManifestStatus is the verification status of a manifest image.
+type ManifestStatus int
+
+const (
+	// ManifestOK is an image matching its manifest record.
+	ManifestOK ManifestStatus = iota
+	// ManifestMissing is an image which no longer exists.
+	ManifestMissing
+	// ManifestDrift is an image whose size or checksum differs from its manifest record.
+	ManifestDrift
+	// ManifestCorrupt is an image which could not be read to compute its checksum.
+	ManifestCorrupt
+)
+
+func (s ManifestStatus) String() string {
+	switch s {
+	case ManifestOK:
+		return "ok"
+	case ManifestMissing:
+		return "missing"
+	case ManifestDrift:
+		return "drift"
+	case ManifestCorrupt:
+		return "corrupt"
+	}
+	return "ManifestStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ManifestResult is the verification result of a manifest image.
+type ManifestResult struct {
+	// Record is the manifest record of the image.
+	Record ImageRecord
+
+	Status ManifestStatus
+
+	// Size and Checksum is the current size and checksum of the image, if known.
+	Size     int64
+	Checksum string
+
+	// Err is the error reading the image, for ManifestMissing and ManifestCorrupt.
+	Err error
+}
+
+// Report is the verification report of a manifest, in the order of the manifest records.
+type Report struct {
+	Results []ManifestResult
+}
+
+// OK reports whether all the manifest images match their records.
+func (r Report) OK() bool {
+	for _, res := range r.Results {
+		if res.Status != ManifestOK {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the results of the images not matching their records.
+func (r Report) Failed() []ManifestResult {
+	var failed []ManifestResult
+	for _, res := range r.Results {
+		if res.Status != ManifestOK {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// VerifyManifest re-checksums the images listed in the manifest written by WriteManifest at manifestPath, and reports the missing, drifted and corrupt images.
+//
+// The image paths are resolved relative to the manifest directory. The images are checksummed in parallel.
+// The records without a checksum, which could not be inspected by Inventory, are only checked for existence and size.
+// The returned error is only about reading the manifest; the image failures are in the Report.
+func VerifyManifest(manifestPath string) (Report, error) {
+	return DefaultClient.VerifyManifest(manifestPath)
+}
+
+// VerifyManifest is like the package-level VerifyManifest, but runs hdiutil with the configuration of c.
+func (c *Client) VerifyManifest(manifestPath string) (Report, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return Report{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Report{}, fmt.Errorf("%s: %w", manifestPath, err)
+	}
+	dir := filepath.Dir(manifestPath)
+
+	report := Report{Results: make([]ManifestResult, len(m.Images))}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, rec := range m.Images {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, rec ImageRecord) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			report.Results[i] = c.verifyRecord(dir, rec)
+		}(i, rec)
+	}
+	wg.Wait()
+
+	return report, nil
+}
+
+// verifyRecord verifies the image of rec, relative to dir.
+func (c *Client) verifyRecord(dir string, rec ImageRecord) ManifestResult {
+	res := ManifestResult{Record: rec}
+
+	path := filepath.Join(dir, filepath.FromSlash(rec.Path))
+	fi, err := os.Stat(path)
+	if err != nil {
+		res.Status = ManifestMissing
+		res.Err = err
+		return res
+	}
+	if res.Size, err = diskSize(path, fi); err != nil {
+		res.Status = ManifestCorrupt
+		res.Err = err
+		return res
+	}
+
+	if rec.Checksum != "" {
+		typ := rec.ChecksumType
+		if typ == "" {
+			typ = inventoryChecksum
+		}
+		sum, err := c.Checksum(path, typ)
+		if err != nil {
+			res.Status = ManifestCorrupt
+			res.Err = err
+			return res
+		}
+		res.Checksum = sum.Hex
+		if recorded, err := ParseChecksumValue(typ, rec.Checksum); err != nil || !sum.Equal(recorded) {
+			res.Status = ManifestDrift
+		}
+	}
+
+	if res.Size != rec.Size {
+		res.Status = ManifestDrift
+	}
+
+	return res
+}
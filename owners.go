@@ -0,0 +1,95 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// UnknownID is the magic "unknown" user and group ID, which the files of volumes mounted with owners off appear to be owned by.
+const UnknownID = 99
+
+// InvokingUser returns the user and group IDs of the user invoking the process, which is the sudo(8) user when run with sudo.
+func InvokingUser() (uid, gid int) {
+	uid, gid = os.Getuid(), os.Getgid()
+	if s, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil && uid == 0 {
+		uid = s
+		if g, err := strconv.Atoi(os.Getenv("SUDO_GID")); err == nil {
+			gid = g
+		}
+	}
+	return uid, gid
+}
+
+// ChownTree changes the owner of every file under root, root included, to uid and gid. Symbolic links are not followed.
+func ChownTree(root string, uid, gid int) error {
+	return chownTree(root, uid, gid, func(fs.FileInfo) bool { return true })
+}
+
+// MapUnknownOwners changes the owner of the files under root owned by UnknownID, user or group, to uid and gid respectively,
+// leaving the files with a real owner unchanged.
+func MapUnknownOwners(root string, uid, gid int) error {
+	return chownTree(root, uid, gid, func(fi fs.FileInfo) bool {
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		return ok && (st.Uid == UnknownID || st.Gid == UnknownID)
+	})
+}
+
+func chownTree(root string, uid, gid int, match func(fs.FileInfo) bool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !match(fi) {
+			return nil
+		}
+
+		u, g := uid, gid
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+			// keep the real half of a partially unknown owner.
+			if st.Uid != UnknownID && st.Gid == UnknownID {
+				u = int(st.Uid)
+			}
+			if st.Gid != UnknownID && st.Uid == UnknownID {
+				g = int(st.Gid)
+			}
+		}
+		return os.Lchown(path, u, g)
+	})
+}
+
+// AttachOwned attach image with the filesystem owners honored, then gives the files of its volumes owned by UnknownID to the invoking user,
+// so tests running as that user can write into the image regardless of the IDs recorded in it.
+//
+// Changing the owners requires root privileges, unless the files are already owned by the invoking user.
+func AttachOwned(image string, flags ...AttachFlag) (AttachResult, error) {
+	return DefaultClient.AttachOwned(image, flags...)
+}
+
+// AttachOwned is like the package-level AttachOwned, but runs hdiutil with the configuration of c.
+func (c *Client) AttachOwned(image string, flags ...AttachFlag) (AttachResult, error) {
+	results, err := c.AttachAll([]string{image}, append(flags, AttachOwnersOn)...)
+	if err != nil {
+		return AttachResult{}, err
+	}
+	r := results[0]
+
+	uid, gid := InvokingUser()
+	for _, mountPoint := range r.MountPoints() {
+		if err := MapUnknownOwners(mountPoint, uid, gid); err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
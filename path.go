@@ -0,0 +1,69 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+const (
+	defaultHdiutilPath = "/usr/bin/hdiutil"
+	defaultBlessPath   = "/usr/sbin/bless"
+	xattrPath          = "/usr/bin/xattr"
+)
+
+// ErrHdiutilNotFound is the error of the invocations of hdiutil when its binary cannot be found.
+var ErrHdiutilNotFound = errors.New("hdiutil binary not found")
+
+var (
+	hdiutilPathMu sync.Mutex
+	hdiutilPath   string // set by SetHdiutilPath, or resolved by HdiutilPath
+)
+
+// SetHdiutilPath sets the path of the hdiutil binary run by an ExecRunner without Path, such as the default Runner of a Client.
+// An empty path resets it, so that it is looked up again by the next invocation.
+func SetHdiutilPath(path string) {
+	hdiutilPathMu.Lock()
+	defer hdiutilPathMu.Unlock()
+	hdiutilPath = path
+}
+
+// HdiutilPath returns the path of the hdiutil binary: the one set by SetHdiutilPath, else /usr/bin/hdiutil if it exists,
+// else the one found in the PATH directories. The path is resolved once, at the first invocation.
+// The returned error wraps ErrHdiutilNotFound if there is none.
+func HdiutilPath() (string, error) {
+	hdiutilPathMu.Lock()
+	defer hdiutilPathMu.Unlock()
+	if hdiutilPath != "" {
+		return hdiutilPath, nil
+	}
+
+	path, err := lookBinary(defaultHdiutilPath, "hdiutil")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrHdiutilNotFound, err)
+	}
+	hdiutilPath = path
+	return path, nil
+}
+
+// lookBinary returns the path of the binary name: def if it exists, else the one found in the PATH directories.
+func lookBinary(def, name string) (string, error) {
+	if fi, err := os.Stat(def); err == nil && !fi.IsDir() {
+		return def, nil
+	}
+	return exec.LookPath(name)
+}
+
+// WithHdiutilPath sets the path of the hdiutil binary run by the Client, instead of the one returned by HdiutilPath.
+// It is ignored if the Client has a Runner set by WithRunner.
+func WithHdiutilPath(path string) ClientOption {
+	return func(c *Client) {
+		c.hdiutilPath = path
+	}
+}
@@ -0,0 +1,514 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	plistHeader = xml.Header + `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n"
+	plistTime   = "2006-01-02T15:04:05Z"
+)
+
+// plistField is a struct field encoded as a plist dictionary key.
+type plistField struct {
+	name      string
+	index     []int
+	omitempty bool
+}
+
+// plistFields returns the dictionary keys of the struct type t, flattening the embedded structs.
+//
+// The key is taken from the `plist:"key,omitempty"` field tag, or the field name if there is no tag.
+// Fields tagged with "-" are ignored.
+func plistFields(t reflect.Type) []plistField {
+	var fields []plistField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("plist")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, ef := range plistFields(ft) {
+					ef.index = append([]int{i}, ef.index...)
+					fields = append(fields, ef)
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, plistField{name: name, index: []int{i}, omitempty: opts == "omitempty"})
+	}
+	return fields
+}
+
+// marshalPlist returns the XML property list encoding of v.
+func marshalPlist(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(plistHeader)
+	buf.WriteString(`<plist version="1.0">` + "\n")
+	if err := encodePlistValue(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</plist>\n")
+	return buf.Bytes(), nil
+}
+
+func encodePlistValue(buf *bytes.Buffer, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fmt.Errorf("plist: can not encode nil value")
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		fmt.Fprintf(buf, "<date>%s</date>\n", t.UTC().Format(plistTime))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteString("<true/>\n")
+		} else {
+			buf.WriteString("<false/>\n")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(buf, "<integer>%d</integer>\n", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fmt.Fprintf(buf, "<integer>%d</integer>\n", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(buf, "<real>%s</real>\n", strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.String:
+		buf.WriteString("<string>")
+		xml.EscapeText(buf, []byte(v.String()))
+		buf.WriteString("</string>\n")
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			fmt.Fprintf(buf, "<data>%s</data>\n", base64.StdEncoding.EncodeToString(b))
+			return nil
+		}
+		buf.WriteString("<array>\n")
+		for i := 0; i < v.Len(); i++ {
+			if err := encodePlistValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</array>\n")
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("plist: unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		buf.WriteString("<dict>\n")
+		for _, k := range keys {
+			encodePlistKey(buf, k.String())
+			if err := encodePlistValue(buf, v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</dict>\n")
+	case reflect.Struct:
+		buf.WriteString("<dict>\n")
+		for _, f := range plistFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || f.omitempty && fv.IsZero() {
+				continue
+			}
+			encodePlistKey(buf, f.name)
+			if err := encodePlistValue(buf, fv); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("</dict>\n")
+	default:
+		return fmt.Errorf("plist: unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func encodePlistKey(buf *bytes.Buffer, key string) {
+	buf.WriteString("<key>")
+	xml.EscapeText(buf, []byte(key))
+	buf.WriteString("</key>\n")
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false instead of panicking on a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// errPlistEnd is returned by decodePlistValue at the end of the enclosing array or dictionary.
+var errPlistEnd = errors.New("plist: end of container")
+
+// unmarshalPlist decodes the XML property list data into v, which must be a non-nil pointer.
+//
+// Any output preceding the XML document, such as progress messages, is skipped.
+// Dictionary keys without a corresponding struct field are ignored.
+func unmarshalPlist(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("plist: unmarshal into non-pointer %T", v)
+	}
+
+	pv, err := decodePlist(data)
+	if err != nil {
+		return err
+	}
+	return assignPlist(rv.Elem(), pv)
+}
+
+// decodePlist decodes the XML property list data into its generic representation.
+func decodePlist(data []byte) (interface{}, error) {
+	i := bytes.Index(data, []byte("<plist"))
+	if i < 0 {
+		return nil, errors.New("plist: no property list in output")
+	}
+	d := xml.NewDecoder(bytes.NewReader(data[i:]))
+	d.Strict = false
+
+	if _, err := d.Token(); err != nil { // <plist>
+		return nil, fmt.Errorf("plist: %w", err)
+	}
+	v, err := decodePlistValue(d)
+	if err == errPlistEnd {
+		return nil, errors.New("plist: empty property list")
+	}
+	return v, err
+}
+
+// decodePlistValue decodes the next plist element of d into a map[string]interface{}, []interface{}, string, int64, uint64, float64, bool, []byte or time.Time.
+func decodePlistValue(d *xml.Decoder) (interface{}, error) {
+	var se xml.StartElement
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			se = t
+			break
+		}
+		if _, ok := tok.(xml.EndElement); ok {
+			return nil, errPlistEnd
+		}
+	}
+
+	switch se.Name.Local {
+	case "dict":
+		m := make(map[string]interface{})
+		for {
+			key, err := decodePlistValue(d)
+			if err == errPlistEnd {
+				return m, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("plist: dictionary key is %T, not string", key)
+			}
+			v, err := decodePlistValue(d)
+			if err != nil {
+				if err == errPlistEnd {
+					err = fmt.Errorf("plist: missing value for key %q", k)
+				}
+				return nil, err
+			}
+			m[k] = v
+		}
+	case "array":
+		a := []interface{}{}
+		for {
+			v, err := decodePlistValue(d)
+			if err == errPlistEnd {
+				return a, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		return se.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, &se); err != nil {
+		return nil, fmt.Errorf("plist: %w", err)
+	}
+
+	// the strings are kept as is, as volume names and paths may start or end with spaces.
+	switch se.Name.Local {
+	case "key", "string":
+		return text, nil
+	}
+	text = strings.TrimSpace(text)
+
+	switch se.Name.Local {
+	case "integer":
+		if n, err := strconv.ParseInt(text, 0, 64); err == nil {
+			return n, nil
+		}
+		n, err := strconv.ParseUint(text, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid integer %q", text)
+		}
+		return n, nil
+	case "real":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid real %q", text)
+		}
+		return f, nil
+	case "data":
+		b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid data: %w", err)
+		}
+		return b, nil
+	case "date":
+		t, err := time.Parse(plistTime, text)
+		if err != nil {
+			return nil, fmt.Errorf("plist: invalid date %q", text)
+		}
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("plist: unknown element <%s>", se.Name.Local)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// assignPlist stores the generic plist value pv into v.
+func assignPlist(v reflect.Value, pv interface{}) error {
+	if pv == nil {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignPlist(v.Elem(), pv)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(pv))
+			return nil
+		}
+	}
+
+	if v.Type() == timeType {
+		t, ok := pv.(time.Time)
+		if !ok {
+			return plistTypeError(pv, v)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		switch x := pv.(type) {
+		case bool:
+			v.SetBool(x)
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return plistTypeError(pv, v)
+			}
+			v.SetBool(b)
+		default:
+			return plistTypeError(pv, v)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch x := pv.(type) {
+		case int64:
+			v.SetInt(x)
+		case uint64:
+			v.SetInt(int64(x))
+		case string:
+			n, err := strconv.ParseInt(x, 0, 64)
+			if err != nil {
+				return plistTypeError(pv, v)
+			}
+			v.SetInt(n)
+		default:
+			return plistTypeError(pv, v)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch x := pv.(type) {
+		case int64:
+			v.SetUint(uint64(x))
+		case uint64:
+			v.SetUint(x)
+		case string:
+			n, err := strconv.ParseUint(x, 0, 64)
+			if err != nil {
+				return plistTypeError(pv, v)
+			}
+			v.SetUint(n)
+		default:
+			return plistTypeError(pv, v)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch x := pv.(type) {
+		case float64:
+			v.SetFloat(x)
+		case int64:
+			v.SetFloat(float64(x))
+		case uint64:
+			v.SetFloat(float64(x))
+		default:
+			return plistTypeError(pv, v)
+		}
+	case reflect.String:
+		switch x := pv.(type) {
+		case string:
+			v.SetString(x)
+		case int64, uint64, float64, bool:
+			v.SetString(fmt.Sprint(x))
+		default:
+			return plistTypeError(pv, v)
+		}
+	case reflect.Slice:
+		if b, ok := pv.([]byte); ok && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(b)
+			return nil
+		}
+		a, ok := pv.([]interface{})
+		if !ok {
+			return plistTypeError(pv, v)
+		}
+		s := reflect.MakeSlice(v.Type(), len(a), len(a))
+		for i, e := range a {
+			if err := assignPlist(s.Index(i), e); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		m, ok := pv.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return plistTypeError(pv, v)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for k, e := range m {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := assignPlist(ev, e); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), ev)
+		}
+	case reflect.Struct:
+		m, ok := pv.(map[string]interface{})
+		if !ok {
+			return plistTypeError(pv, v)
+		}
+		for _, f := range plistFields(v.Type()) {
+			e, ok := m[f.name]
+			if !ok {
+				continue
+			}
+			fv := v
+			for i, x := range f.index {
+				if i > 0 && fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						fv.Set(reflect.New(fv.Type().Elem()))
+					}
+					fv = fv.Elem()
+				}
+				fv = fv.Field(x)
+			}
+			if err := assignPlist(fv, e); err != nil {
+				return fmt.Errorf("%w (key %q)", err, f.name)
+			}
+		}
+	default:
+		return plistTypeError(pv, v)
+	}
+
+	return nil
+}
+
+func plistTypeError(pv interface{}, v reflect.Value) error {
+	return fmt.Errorf("plist: can not decode %T into %s", pv, v.Type())
+}
+
+// ParseCreatePlist decodes the hdiutil create -plist and convert -plist output data into the paths of the written files.
+func ParseCreatePlist(data []byte) ([]string, error) {
+	var paths []string
+	if err := unmarshalPlist(data, &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// runPlist runs cmd, created by plistCommand, and returns its property list output, to be decoded by the Parse functions.
+func (c *Client) runPlist(cmd *command) ([]byte, error) {
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// rawPlist returns the XML property list document of the output out, without the output preceding it.
+func rawPlist(out []byte) []byte {
+	if i := bytes.Index(out, []byte("<?xml")); i >= 0 {
+		return out[i:]
+	}
+	if i := bytes.Index(out, []byte("<plist")); i >= 0 {
+		return out[i:]
+	}
+	return out
+}
@@ -0,0 +1,218 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseInfoPlist(t *testing.T) {
+	info, err := ParseInfoPlist(readTestdata(t, "info.plist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Framework != "671.100.2" || info.Vendor != "Apple" {
+		t.Errorf("framework %q vendor %q", info.Framework, info.Vendor)
+	}
+	if len(info.Images) != 1 {
+		t.Fatalf("got %d images, want 1", len(info.Images))
+	}
+
+	img := info.Images[0]
+	want := InfoImage{
+		ImagePath:  "/Users/gopher/Downloads/My Image .dmg",
+		ImageType:  "read-only disk image",
+		Removable:  true,
+		BlockCount: 204800,
+		BlockSize:  512,
+		OwnerUID:   501,
+		SystemEntities: []SystemEntity{
+			{DevEntry: "/dev/disk4", ContentHint: "GUID_partition_scheme", UnmappedContentHint: "GUID_partition_scheme"},
+			{
+				DevEntry:             "/dev/disk4s1",
+				ContentHint:          "Apple_HFS",
+				MountPoint:           "/Volumes/ My Volume ",
+				VolumeKind:           "hfs",
+				UnmappedContentHint:  "48465300-0000-11AA-AA11-00306543ECAC",
+				PotentiallyMountable: true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(img, want) {
+		t.Errorf("image:\ngot  %+v\nwant %+v", img, want)
+	}
+	if got := img.Size(); got != 100<<20 {
+		t.Errorf("size = %d, want %d", got, 100<<20)
+	}
+}
+
+func TestParseAttachPlist(t *testing.T) {
+	data := readTestdata(t, "attach.plist")
+	res, err := ParseAttachPlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.SystemEntities) != 4 {
+		t.Fatalf("got %d entities, want 4", len(res.SystemEntities))
+	}
+	if got := res.SystemEntities[3].MountPoint; got != "/Volumes/Install Go" {
+		t.Errorf("mount point = %q", got)
+	}
+	if !bytes.HasPrefix(res.Raw, []byte("<?xml")) {
+		t.Errorf("raw output keeps the progress preceding the property list: %.40q", res.Raw)
+	}
+}
+
+func TestParseImageInfoPlist(t *testing.T) {
+	info, err := ParseImageInfoPlist(readTestdata(t, "imageinfo.plist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != "UDZO" || info.ChecksumType != "CRC32" || info.ChecksumValue != "$A2E4E1C1" {
+		t.Errorf("format %q checksum %q %q", info.Format, info.ChecksumType, info.ChecksumValue)
+	}
+	wantSize := ImageSizeInfo{
+		TotalBytes:         10485760,
+		CompressedBytes:    1112553,
+		CompressedRatio:    0.10610122680664063,
+		SectorCount:        20480,
+		TotalEmptyBytes:    8126464,
+		TotalNonEmptyBytes: 2359296,
+	}
+	if info.SizeInformation != wantSize {
+		t.Errorf("size information:\ngot  %+v\nwant %+v", info.SizeInformation, wantSize)
+	}
+	wantProps := ImageProperties{Checksummed: true, Compressed: true, KernelCompatible: true}
+	if info.Properties != wantProps {
+		t.Errorf("properties = %+v, want %+v", info.Properties, wantProps)
+	}
+}
+
+func TestParseIsEncryptedPlist(t *testing.T) {
+	info, err := ParseIsEncryptedPlist(readTestdata(t, "isencrypted.plist"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Raw = nil
+	want := EncryptionInfo{
+		Encrypted:       true,
+		UUID:            "5B2C4A1E-7F7C-4D4B-9B0E-6C1D2A3F4E5D",
+		BlockSize:       512,
+		PassphraseCount: 1,
+		MaxKeyCount:     1,
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("got %+v, want %+v", info, want)
+	}
+}
+
+func TestDecodePlistValue(t *testing.T) {
+	tests := []struct {
+		elem string
+		want interface{}
+	}{
+		{"<string> padded </string>", " padded "},
+		{"<string>a &amp; b</string>", "a & b"},
+		{"<string></string>", ""},
+		{"<integer>\n\t42\n</integer>", int64(42)},
+		{"<integer>-1</integer>", int64(-1)},
+		{"<integer>18446744073709551615</integer>", uint64(18446744073709551615)},
+		{"<real> 0.5 </real>", 0.5},
+		{"<true/>", true},
+		{"<false/>", false},
+		{"<data>\n\taGVs\n\tbG8=\n</data>", []byte("hello")},
+		{"<date> 2017-06-01T12:30:00Z </date>", time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC)},
+		{"<array><integer>1</integer><string>x</string></array>", []interface{}{int64(1), "x"}},
+		{"<dict><key>k</key><string> v</string></dict>", map[string]interface{}{"k": " v"}},
+	}
+	for _, tt := range tests {
+		got, err := decodePlist([]byte(`<plist version="1.0">` + tt.elem + `</plist>`))
+		if err != nil {
+			t.Errorf("%s: %v", tt.elem, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.elem, got, tt.want)
+		}
+	}
+}
+
+func TestDecodePlistErrors(t *testing.T) {
+	for _, data := range []string{
+		"no property list",
+		`<plist version="1.0"></plist>`,
+		`<plist version="1.0"><integer>x</integer></plist>`,
+		`<plist version="1.0"><dict><key>k</key></dict></plist>`,
+		`<plist version="1.0"><dict><integer>1</integer><string>v</string></dict></plist>`,
+		`<plist version="1.0"><unknown/></plist>`,
+	} {
+		if _, err := decodePlist([]byte(data)); err == nil {
+			t.Errorf("decodePlist(%q) succeeded", data)
+		}
+	}
+}
+
+func TestPlistRoundTrip(t *testing.T) {
+	type embedded struct {
+		Inner string `plist:"inner"`
+	}
+	type value struct {
+		embedded
+		Name    string            `plist:"name"`
+		Count   int64             `plist:"count"`
+		Ratio   float64           `plist:"ratio"`
+		OK      bool              `plist:"ok"`
+		Data    []byte            `plist:"data"`
+		When    time.Time         `plist:"when"`
+		List    []string          `plist:"list"`
+		Map     map[string]int    `plist:"map"`
+		Empty   string            `plist:"empty,omitempty"`
+		Ignored string            `plist:"-"`
+		Nested  *InfoImage        `plist:"nested"`
+		Extra   map[string]string `plist:"extra,omitempty"`
+	}
+	in := value{
+		embedded: embedded{Inner: "inner"},
+		Name:     " name with <markup> & spaces ",
+		Count:    -7,
+		Ratio:    0.25,
+		OK:       true,
+		Data:     []byte{0, 1, 2, 0xff},
+		When:     time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC),
+		List:     []string{"a", "b"},
+		Map:      map[string]int{"x": 1, "y": 2},
+		Nested:   &InfoImage{ImagePath: "/tmp/a.dmg", BlockSize: 512, SystemEntities: []SystemEntity{{DevEntry: "/dev/disk4"}}},
+	}
+
+	data, err := marshalPlist(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("<key>empty</key>")) {
+		t.Error("omitempty field is encoded")
+	}
+
+	var out value
+	if err := unmarshalPlist(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip:\ngot  %+v\nwant %+v", out, in)
+	}
+}
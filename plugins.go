@@ -0,0 +1,58 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// Plugin is a DiskImages framework plugin, reported by hdiutil plugins.
+type Plugin struct {
+	// Key is the identifier of the plugin, such as CUDIFDiskImage.
+	Key string `plist:"plugin-key"`
+
+	Name    string `plist:"plugin-name"`
+	Version string `plist:"plugin-version"`
+	Path    string `plist:"plugin-path"`
+
+	// Class is the kind of the plugin, such as a disk image or a compressor.
+	Class string `plist:"plugin-class"`
+}
+
+// pluginsList is the hdiutil plugins -plist output.
+type pluginsList struct {
+	Plugins []Plugin `plist:"plugins"`
+}
+
+// PluginsFlag is a hdiutil plugins command flag, returning its command-line arguments.
+type PluginsFlag interface {
+	PluginsFlag() []string
+}
+
+// Plugins returns the DiskImages plugins available on the system,
+// so that a program can check the host supports a format, such as ULFO or UDBZ, before using it.
+func Plugins(flags ...PluginsFlag) ([]Plugin, error) {
+	return DefaultClient.Plugins(flags...)
+}
+
+// Plugins is like the package-level Plugins, but runs hdiutil with the configuration of c.
+func (c *Client) Plugins(flags ...PluginsFlag) ([]Plugin, error) {
+	cmd := c.plistCommand("plugins")
+	for _, flag := range flags {
+		cmd.flag(flag, flag.PluginsFlag())
+	}
+
+	out, err := c.runPlist(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePluginsPlist(out)
+}
+
+// ParsePluginsPlist decodes the hdiutil plugins -plist output data.
+func ParsePluginsPlist(data []byte) ([]Plugin, error) {
+	var list pluginsList
+	if err := unmarshalPlist(data, &list); err != nil {
+		return nil, err
+	}
+	return list.Plugins, nil
+}
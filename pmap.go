@@ -0,0 +1,141 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PartitionMap is the partition map of an image or device, reported by hdiutil pmap.
+type PartitionMap struct {
+	// Scheme is the partition scheme, such as GUID or APM, if reported.
+	Scheme string
+
+	// Entries is the partition map entries, in the order of the map.
+	Entries []PartitionEntry
+}
+
+// PartitionEntry is an entry of a partition map.
+type PartitionEntry struct {
+	// Number is the partition number, -1 for the entry of the map itself.
+	Number int
+
+	// DevNode is the device node of the partition, such as disk4s1, if the image is attached.
+	DevNode string
+
+	// Type is the partition type, such as Apple_HFS or Apple_Free.
+	Type string
+
+	Name string
+
+	// Start and Length is the position of the partition, in 512-byte sectors.
+	Start  int64
+	Length int64
+}
+
+// PmapFlag is a hdiutil pmap command flag, returning its command-line arguments.
+type PmapFlag interface {
+	PmapFlag() []string
+}
+
+// Pmap returns the partition map of an image or device.
+func Pmap(imageOrDevice string, flags ...PmapFlag) (*PartitionMap, error) {
+	return DefaultClient.Pmap(imageOrDevice, flags...)
+}
+
+// Pmap is like the package-level Pmap, but runs hdiutil with the configuration of c.
+func (c *Client) Pmap(imageOrDevice string, flags ...PmapFlag) (*PartitionMap, error) {
+	cmd := c.command("pmap")
+	cmd.target = imageOrDevice
+	for _, flag := range flags {
+		cmd.flag(flag, flag.PmapFlag())
+	}
+	cmd.args = append(cmd.args, imageOrDevice)
+
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePmap(out)
+}
+
+// parsePmap parses the hdiutil pmap output, a table of the partitions such as
+//
+//	Partition List
+//	## Dev_Node   Type                 Name                  Start     Size      End
+//	-1            Apple_partition_map  Apple                 1         63        63
+//	 0 disk4s1    Apple_HFS            disk image            64        409472    409535
+//
+// The names may contain spaces, but not the types, so the columns are told apart by their position from both ends.
+func parsePmap(out []byte) (*PartitionMap, error) {
+	pm := new(PartitionMap)
+	header := false
+
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if scheme, ok := strings.CutPrefix(line, "Scheme:"); ok {
+			pm.Scheme = strings.TrimSpace(scheme)
+			continue
+		}
+		if strings.HasPrefix(line, "##") {
+			header = true
+			continue
+		}
+		if !header {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		number, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		// the trailing numbers are the start, size and end sectors.
+		var nums []int64
+		end := len(fields)
+		for end > 2 && len(nums) < 3 {
+			n, err := strconv.ParseInt(fields[end-1], 10, 64)
+			if err != nil {
+				break
+			}
+			nums = append([]int64{n}, nums...)
+			end--
+		}
+		if len(nums) < 2 {
+			continue
+		}
+
+		e := PartitionEntry{Number: number, Start: nums[0], Length: nums[1]}
+		i := 1
+		if strings.HasPrefix(fields[i], "disk") || strings.HasPrefix(fields[i], "/dev/") {
+			e.DevNode = fields[i]
+			i++
+		}
+		if i >= end {
+			continue
+		}
+		e.Type = fields[i]
+		e.Name = strings.Join(fields[i+1:end], " ")
+		pm.Entries = append(pm.Entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !header {
+		return nil, fmt.Errorf("no partition list in hdiutil pmap output: %s", out)
+	}
+
+	return pm, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePmap(t *testing.T) {
+	out := `Partition List
+## Dev_Node   Type                 Name                  Start     Size      End
+-1            Apple_partition_map  Apple                 1         63        63
+ 0 disk4s1    Apple_HFS            disk image            64        409472    409535
+ 1            Apple_Free                                 409536    10        409545
+`
+	got, err := parsePmap([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PartitionMap{Entries: []PartitionEntry{
+		{Number: -1, Type: "Apple_partition_map", Name: "Apple", Start: 1, Length: 63},
+		{Number: 0, DevNode: "disk4s1", Type: "Apple_HFS", Name: "disk image", Start: 64, Length: 409472},
+		{Number: 1, Type: "Apple_Free", Start: 409536, Length: 10},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParsePmapScheme(t *testing.T) {
+	out := `Scheme: GUID
+Partition List
+## Dev_Node   Type                 Name                  Start     Size      End
+ 1 /dev/disk5s1 EFI                EFI System Partition  40        409600    409639
+`
+	got, err := parsePmap([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &PartitionMap{Scheme: "GUID", Entries: []PartitionEntry{
+		{Number: 1, DevNode: "/dev/disk5s1", Type: "EFI", Name: "EFI System Partition", Start: 40, Length: 409600},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParsePmapNoList(t *testing.T) {
+	if _, err := parsePmap([]byte("hdiutil: pmap failed - image not recognized\n")); err == nil {
+		t.Error("output without partition list accepted")
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPolicyViolation is wrapped by the errors of the invocations rejected by the Client Policy.
+var ErrPolicyViolation = errors.New("policy violation")
+
+// Policy restricts the hdiutil verbs and flags a Client may run, for exposing image operations to semi-trusted automation.
+type Policy struct {
+	// DenyVerbs is the verbs which are never run, such as "erasekeys", or the helper tools, such as "diskutil".
+	DenyVerbs []string
+
+	// DenyFlags is the flags which are never passed, such as "-insecurehttp". The leading "-" is optional.
+	DenyFlags []string
+
+	// NoMount reports whether image is untrusted. Untrusted images are always attached with -nomount,
+	// overriding any mount flag such as AttachMountPoint.
+	NoMount func(image string) bool
+}
+
+// WithPolicy enforces p on every invocation of the Client.
+// The invocations violating p are not run and return an error wrapping ErrPolicyViolation.
+func WithPolicy(p Policy) ClientOption {
+	return func(c *Client) {
+		c.policy = &p
+	}
+}
+
+// mountArgs is the attach flags taking a value which mount the image volumes.
+var mountArgs = map[string]bool{
+	"-mount":       true,
+	"-mountpoint":  true,
+	"-mountroot":   true,
+	"-mountrandom": true,
+}
+
+// enforcePolicy checks cmd against the Client Policy, rewriting the attach of untrusted images.
+func (c *Client) enforcePolicy(cmd *command) error {
+	p := c.policy
+	if p == nil {
+		return nil
+	}
+
+	for _, verb := range p.DenyVerbs {
+		if verb == cmd.verb {
+			return fmt.Errorf("%s: %w: verb is denied", cmd.verb, ErrPolicyViolation)
+		}
+	}
+	for _, flag := range p.DenyFlags {
+		flag = "-" + strings.TrimPrefix(flag, "-")
+		if cmd.hasArg(flag) {
+			return fmt.Errorf("%s: %w: flag %s is denied", cmd.verb, ErrPolicyViolation, flag)
+		}
+	}
+
+	if cmd.verb == "attach" && p.NoMount != nil && p.noMount(cmd) {
+		args := make([]string, 0, len(cmd.args)+1)
+		for i := 0; i < len(cmd.args); i++ {
+			if mountArgs[cmd.args[i]] {
+				i++ // skip the value
+				continue
+			}
+			args = append(args, cmd.args[i])
+		}
+		cmd.args = args
+		if !cmd.hasArg("-nomount") {
+			cmd.args = append(cmd.args, "-nomount")
+		}
+	}
+
+	return nil
+}
+
+// noMount reports whether any of the images attached by cmd must not be mounted.
+func (p *Policy) noMount(cmd *command) bool {
+	for _, image := range cmd.allTargets() {
+		if p.NoMount(image) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// ErrInvalidDevice is the error of an invocation whose target under /dev is not a disk device node, such as /dev/disk4s1.
+var ErrInvalidDevice = errors.New("not a disk device node")
+
+// newImageVerbs is the verbs whose target is the image they create, which does not exist yet.
+var newImageVerbs = map[string]bool{
+	"create":     true,
+	"makehybrid": true,
+}
+
+// deviceNodeRe matches the disk device nodes, whole disks and slices, block or raw.
+var deviceNodeRe = regexp.MustCompile(`^/dev/r?disk\d+(s\d+)*$`)
+
+// WithoutPreflight disables the checks of the targets and outputs made before running hdiutil.
+//
+// By default, the images the verbs read must exist, the targets under /dev must be disk device nodes,
+// and the directories of the new images must exist and be writable, so that the verbs fail with a descriptive error
+// wrapping fs.ErrNotExist, fs.ErrPermission or ErrInvalidDevice rather than with the one of hdiutil.
+// The checks are skipped if the Client has a Runner set by WithRunner, as the paths may not be local,
+// and the writability is not checked with WithSudo.
+func WithoutPreflight() ClientOption {
+	return func(c *Client) {
+		c.noPreflight = true
+	}
+}
+
+// preflight checks the targets and output of cmd before it is run.
+func (c *Client) preflight(cmd *command) error {
+	if c.noPreflight || c.runner != nil {
+		return nil
+	}
+
+	if !newImageVerbs[cmd.verb] {
+		for _, target := range cmd.allTargets() {
+			if err := checkTarget(target); err != nil {
+				return fmt.Errorf("%s: %w", cmd.verb, err)
+			}
+		}
+	}
+
+	// the images modified in place, such as by resize, are not created.
+	if cmd.output != "" && (cmd.output != cmd.target || newImageVerbs[cmd.verb]) {
+		if err := c.checkOutputDir(filepath.Dir(cmd.output)); err != nil {
+			return fmt.Errorf("%s %s: %w", cmd.verb, cmd.output, err)
+		}
+	}
+
+	return nil
+}
+
+// checkTarget checks that target, an image or device read by a verb, exists.
+// The URLs of remote images and the bare device names accepted by hdiutil, such as disk2s1, are not checked.
+func checkTarget(target string) error {
+	switch {
+	case target == "", strings.Contains(target, "://"):
+		return nil
+	case strings.HasPrefix(target, "/dev/") && !deviceNodeRe.MatchString(target):
+		return fmt.Errorf("%s: %w", target, ErrInvalidDevice)
+	case !strings.ContainsRune(target, '/') && deviceNodeRe.MatchString("/dev/"+target):
+		return nil
+	}
+	_, err := os.Stat(target)
+	return err
+}
+
+// checkOutputDir checks that dir, the directory of an image written by a verb, is a writable directory.
+func (c *Client) checkOutputDir(dir string) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if c.sudo {
+		return nil
+	}
+	const wOK = 0x2
+	if err := syscall.Access(dir, wOK); err != nil {
+		return &fs.PathError{Op: "access", Path: dir, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckTarget(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		target string
+		want   error
+	}{
+		{"", nil},
+		{"https://example.com/image.dmg", nil},
+		{"disk2", nil},
+		{"disk2s1", nil},
+		{"rdisk2s1", nil},
+		{"/dev/null", ErrInvalidDevice},
+		{"/dev/disk2x", ErrInvalidDevice},
+		{dir, nil},
+		{filepath.Join(dir, "missing.dmg"), fs.ErrNotExist},
+		{"disk2.dmg", fs.ErrNotExist},
+	}
+	for _, tt := range tests {
+		err := checkTarget(tt.target)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("checkTarget(%q) = %v, want %v", tt.target, err, tt.want)
+		}
+	}
+}
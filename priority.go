@@ -0,0 +1,59 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "strconv"
+
+// taskpolicyPath is the path of the taskpolicy binary.
+const taskpolicyPath = "/usr/sbin/taskpolicy"
+
+// Priority is the CPU and I/O priority hdiutil runs with, set with taskpolicy(8).
+type Priority int
+
+const (
+	// PriorityDefault runs hdiutil with the priority of the current process.
+	PriorityDefault Priority = iota
+
+	// PriorityUtility clamps hdiutil to the utility QoS, the one of the user-visible long running tasks,
+	// which lowers its CPU priority and throttles its I/O when the interactive session needs them.
+	PriorityUtility
+
+	// PriorityBackground runs hdiutil with the background policy, the lowest CPU priority and the most throttled I/O,
+	// for the work nobody waits for, such as a nightly conversion. It may be much slower, even on an idle machine.
+	PriorityBackground
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityDefault:
+		return "default"
+	case PriorityUtility:
+		return "utility"
+	case PriorityBackground:
+		return "background"
+	}
+	return "Priority(" + strconv.Itoa(int(p)) + ")"
+}
+
+// taskpolicyArgs returns the taskpolicy arguments setting p, or nil for PriorityDefault.
+func (p Priority) taskpolicyArgs() []string {
+	switch p {
+	case PriorityUtility:
+		return []string{"-c", "utility"}
+	case PriorityBackground:
+		return []string{"-b"}
+	}
+	return nil
+}
+
+// WithPriority runs the hdiutil processes of the Client with the priority p, so that a large conversion or verification
+// does not starve the interactive session. The priority also applies to the helper processes of hdiutil.
+//
+// It is ignored if the Client has a Runner set by WithRunner.
+func WithPriority(p Priority) ClientOption {
+	return func(c *Client) {
+		c.priority = p
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs x in a new process group, and makes the cancellation of its context kill the whole group,
+// so that the helper processes started by hdiutil, such as diskimages-helper, do not keep holding the device.
+func setProcessGroup(x *exec.Cmd) {
+	x.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	x.Cancel = func() error {
+		return syscall.Kill(-x.Process.Pid, syscall.SIGKILL)
+	}
+}
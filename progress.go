@@ -0,0 +1,132 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Progress is a progress report of a long running hdiutil operation, parsed from the Puppetstrings and Verbose output.
+//
+// A progress function given to a verb is called from a single goroutine, in the order of the reports.
+// It is not called anymore once the context of the call is done, and never after the verb returns:
+// the goroutine reading the output of hdiutil has exited by then, including when hdiutil was killed on cancellation.
+// A progress function blocking delays the verb, as hdiutil blocks writing its output.
+type Progress struct {
+	// Percent is the completion percentage, or -1 if hdiutil is performing an operation that will take an indeterminate amount of time to complete.
+	// Percent is only valid if Message is empty.
+	Percent float64
+
+	// Message is a progress message, such as the DiscRecording content creation phase being run, or a verbose output line.
+	Message string
+}
+
+// parseProgress parses a line of the -puppetstrings output.
+func parseProgress(line string) (Progress, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Progress{}, false
+	}
+
+	switch {
+	case strings.HasPrefix(line, "PERCENT:"):
+		p, err := strconv.ParseFloat(strings.TrimPrefix(line, "PERCENT:"), 64)
+		if err != nil {
+			return Progress{Message: line}, true
+		}
+		return Progress{Percent: p}, true
+	case strings.HasPrefix(line, "MESSAGE:"):
+		return Progress{Message: strings.TrimPrefix(line, "MESSAGE:")}, true
+	}
+
+	return Progress{Message: line}, true
+}
+
+// scanProgressLines is a bufio.SplitFunc splitting on both of '\n' and '\r', as the progress output may redraw a line.
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// progressTail is the number of output lines kept to report an error of a progress run.
+const progressTail = 20
+
+// ProgressTo returns a progress function sending the reports to ch, for a consumer reading them from another goroutine.
+//
+// A report is dropped instead of being sent once ctx is done, so that the verb is not blocked by a consumer which stopped
+// receiving on cancellation. ch is not closed: the reports are over when the verb returns.
+func ProgressTo(ctx context.Context, ch chan<- Progress) func(Progress) {
+	return func(p Progress) {
+		select {
+		case ch <- p:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// runProgress runs cmd and calls progress for each progress report of its combined output, until ctx is done.
+// The output is also copied to out, if it is not nil.
+// The returns last lines of the output, for diagnostics, and error.
+func runProgress(ctx context.Context, cmd *exec.Cmd, progress func(Progress), out io.Writer) ([]byte, error) {
+	pr, pw := io.Pipe()
+	cmd.Stdout = teeWriter(pw, out)
+	cmd.Stderr = cmd.Stdout
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var tail []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sc := bufio.NewScanner(pr)
+		sc.Split(scanProgressLines)
+		for sc.Scan() {
+			p, ok := parseProgress(sc.Text())
+			if !ok {
+				continue
+			}
+			if p.Message != "" {
+				if tail = append(tail, p.Message); len(tail) > progressTail {
+					tail = tail[1:]
+				}
+			}
+			if progress != nil && ctx.Err() == nil {
+				progress(p)
+			}
+		}
+		// drain the pipe so that hdiutil never blocks on a write if the scanner fails.
+		io.Copy(io.Discard, pr)
+	}()
+
+	err := cmd.Wait()
+	pw.Close()
+	<-done
+
+	return []byte(strings.Join(tail, "\n")), err
+}
+
+// replayProgress calls progress for each progress report of the output out of a completed run, until ctx is done.
+func replayProgress(ctx context.Context, out []byte, progress func(Progress)) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Split(scanProgressLines)
+	for sc.Scan() && ctx.Err() == nil {
+		if p, ok := parseProgress(sc.Text()); ok {
+			progress(p)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrReadOnly is wrapped by the errors of the invocations rejected by the Client read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// shadowedVerbs is the verbs which write their input image in place, but accept a shadow file to redirect the writes.
+var shadowedVerbs = map[string]bool{
+	"compact": true,
+	"resize":  true,
+}
+
+// inPlaceVerbs is the verbs which modify their input image in place without the possibility of a shadow file.
+var inPlaceVerbs = map[string]bool{
+	"chpass":          true,
+	"erasekeys":       true,
+	"flatten":         true,
+	"internet-enable": true,
+	"udifrez":         true,
+	"unflatten":       true,
+}
+
+// WithReadOnly enables the read-only mode of the Client, a safety harness for forensic and auditing tools.
+//
+// In read-only mode, images are attached with -readonly, or with a shadow file (see WithTempDir) if AttachReadWrite is given,
+// the verbs which write their input image use a shadow file and those which can not are rejected,
+// and the images created or converted under any of the protected directories are rejected.
+// The rejected invocations return an error wrapping ErrReadOnly.
+func WithReadOnly(protected ...string) ClientOption {
+	return func(c *Client) {
+		c.readOnly = true
+		for _, p := range protected {
+			c.protected = append(c.protected, filepath.Clean(p))
+		}
+	}
+}
+
+// enforceReadOnly rewrites or rejects cmd according to the Client read-only mode.
+func (c *Client) enforceReadOnly(cmd *command) error {
+	if !c.readOnly {
+		return nil
+	}
+
+	if cmd.output != "" && c.isProtected(cmd.output) {
+		return fmt.Errorf("%s %s: %w: output is in a protected directory", cmd.verb, cmd.output, ErrReadOnly)
+	}
+
+	switch {
+	case cmd.verb == "attach":
+		if cmd.hasArg("-readwrite") {
+			if len(cmd.allTargets()) > 1 {
+				return fmt.Errorf("%s: %w: several images can not share a shadow file", cmd.verb, ErrReadOnly)
+			}
+			c.shadow(cmd)
+		} else if !cmd.hasArg("-readonly") {
+			cmd.args = append(cmd.args, "-readonly")
+		}
+	case shadowedVerbs[cmd.verb]:
+		c.shadow(cmd)
+	case inPlaceVerbs[cmd.verb], cmd.modifies:
+		return fmt.Errorf("%s %s: %w: verb modifies the image in place", cmd.verb, cmd.target, ErrReadOnly)
+	}
+
+	return nil
+}
+
+// shadow adds a shadow file in the Client temporary directory to cmd, unless it already uses one.
+func (c *Client) shadow(cmd *command) {
+	if cmd.hasArg("-shadow") {
+		return
+	}
+	name := strings.TrimSuffix(filepath.Base(cmd.target), filepath.Ext(cmd.target))
+	path := filepath.Join(c.tempDir(), name+"-"+strconv.FormatInt(time.Now().UnixNano(), 36)+".shadow")
+	cmd.args = append(cmd.args, Shadow(path).AttachFlag()...)
+}
+
+// isProtected reports whether path is under any of the protected directories of the Client.
+func (c *Client) isProtected(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	for _, p := range c.protected {
+		if pa, err := filepath.Abs(p); err == nil {
+			p = pa
+		}
+		if abs == p || strings.HasPrefix(abs, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
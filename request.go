@@ -0,0 +1,309 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CreateRequest is a hdiutil create invocation described with plain fields, such as decoded from a configuration file,
+// as an alternative to the typed flags of Create. The zero fields are not given to hdiutil.
+type CreateRequest struct {
+	Image string `json:"image"`
+
+	// Size is the size of the image, in any form accepted by CreateSize. Only one of Size and Srcfolder can be set.
+	Size string `json:"size,omitempty"`
+
+	// Srcfolder is the directory whose contents are copied into the image, which is sized from it.
+	Srcfolder string `json:"srcfolder,omitempty"`
+
+	// Type is the image type: UDIF, SPARSE or SPARSEBUNDLE.
+	Type string `json:"type,omitempty"`
+
+	// FS is the filesystem, such as APFS or JHFS+.
+	FS string `json:"fs,omitempty"`
+
+	Volname string `json:"volname,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Layout  string `json:"layout,omitempty"`
+
+	// Encryption is the encryption algorithm, AES-128 or AES-256, and Passphrase the passphrase of the encrypted image.
+	Encryption string `json:"encryption,omitempty"`
+	Passphrase string `json:"-"`
+
+	// Overwrite overwrites an existing image, see CreateOV.
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+// Flags returns the size specification and the flags of r to give to Create.
+func (r CreateRequest) Flags() (SizeFlag, []CreateFlag, error) {
+	var size SizeFlag
+	switch {
+	case r.Size != "" && r.Srcfolder != "":
+		return nil, nil, errors.New("create request: only one of size and srcfolder can be set")
+	case r.Size != "":
+		size = CreateSize(r.Size)
+	case r.Srcfolder != "":
+		size = CreateSrcfolder(r.Srcfolder)
+	default:
+		return nil, nil, errors.New("create request: size or srcfolder is required")
+	}
+
+	var flags []CreateFlag
+	if r.Type != "" {
+		t, err := parseCreateType(r.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		flags = append(flags, t)
+	}
+	if r.FS != "" {
+		fs, err := parseCreateFS(r.FS)
+		if err != nil {
+			return nil, nil, err
+		}
+		flags = append(flags, fs)
+	}
+	if r.Volname != "" {
+		flags = append(flags, CreateVolname(r.Volname))
+	}
+	if r.Format != "" {
+		flags = append(flags, CreateFormat(r.Format))
+	}
+	if r.Layout != "" {
+		flags = append(flags, CreateLayout(r.Layout))
+	}
+	if r.Encryption != "" {
+		e, err := parseEncryptionType(r.Encryption)
+		if err != nil {
+			return nil, nil, err
+		}
+		flags = append(flags, e)
+	}
+	if r.Passphrase != "" {
+		flags = append(flags, Passphrase(r.Passphrase))
+	}
+	if r.Overwrite {
+		flags = append(flags, CreateOV)
+	}
+
+	return size, flags, nil
+}
+
+// Args returns the hdiutil create arguments of r, following the verb.
+func (r CreateRequest) Args() ([]string, error) {
+	size, flags, err := r.Flags()
+	if err != nil {
+		return nil, err
+	}
+	args := size.SizeFlag()
+	for _, flag := range flags {
+		args = append(args, flag.CreateFlag()...)
+	}
+	return append(args, r.Image), nil
+}
+
+// Run runs r with c, or DefaultClient if c is nil. The returns created image path and error, like Create.
+func (r CreateRequest) Run(c *Client) (string, error) {
+	size, flags, err := r.Flags()
+	if err != nil {
+		return "", err
+	}
+	if c == nil {
+		c = DefaultClient
+	}
+	return c.Create(r.Image, size, flags...)
+}
+
+// AttachRequest is a hdiutil attach invocation described with plain fields, as an alternative to the typed flags of Attach.
+type AttachRequest struct {
+	Image string `json:"image"`
+
+	Readonly bool `json:"readonly,omitempty"`
+	NoMount  bool `json:"nomount,omitempty"`
+	NoBrowse bool `json:"nobrowse,omitempty"`
+	NoVerify bool `json:"noverify,omitempty"`
+
+	// MountPoint is the mount point of the volume, and MountRoot the directory the volumes are mounted under.
+	MountPoint string `json:"mountpoint,omitempty"`
+	MountRoot  string `json:"mountroot,omitempty"`
+
+	// Owners is "on" or "off" to override the default ownership handling of the volumes.
+	Owners string `json:"owners,omitempty"`
+
+	// Shadow is the shadow file receiving the writes to the image.
+	Shadow string `json:"shadow,omitempty"`
+
+	Passphrase string `json:"-"`
+}
+
+// Flags returns the flags of r to give to Attach.
+func (r AttachRequest) Flags() ([]AttachFlag, error) {
+	var flags []AttachFlag
+	if r.Readonly {
+		flags = append(flags, AttachReadonly)
+	}
+	if r.NoMount {
+		flags = append(flags, AttachNoMount)
+	}
+	if r.NoBrowse {
+		flags = append(flags, AttachNoBrowse)
+	}
+	if r.NoVerify {
+		flags = append(flags, AttachNoVerify)
+	}
+	if r.MountPoint != "" {
+		flags = append(flags, AttachMountPoint(r.MountPoint))
+	}
+	if r.MountRoot != "" {
+		flags = append(flags, AttachMountRoot(r.MountRoot))
+	}
+	switch strings.ToLower(r.Owners) {
+	case "":
+	case "on":
+		flags = append(flags, AttachOwnersOn)
+	case "off":
+		flags = append(flags, AttachOwnersOff)
+	default:
+		return nil, fmt.Errorf("attach request: invalid owners %q", r.Owners)
+	}
+	if r.Shadow != "" {
+		flags = append(flags, Shadow(r.Shadow))
+	}
+	if r.Passphrase != "" {
+		flags = append(flags, Passphrase(r.Passphrase))
+	}
+	return flags, nil
+}
+
+// Args returns the hdiutil attach arguments of r, following the verb.
+func (r AttachRequest) Args() ([]string, error) {
+	flags, err := r.Flags()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{r.Image}
+	for _, flag := range flags {
+		args = append(args, flag.AttachFlag()...)
+	}
+	return args, nil
+}
+
+// Run runs r with c, or DefaultClient if c is nil, like Attach.
+func (r AttachRequest) Run(c *Client) (AttachResult, error) {
+	flags, err := r.Flags()
+	if err != nil {
+		return AttachResult{ImagePath: r.Image, Err: err}, err
+	}
+	if c == nil {
+		c = DefaultClient
+	}
+	return c.Attach(r.Image, flags...)
+}
+
+// ConvertRequest is a hdiutil convert invocation described with plain fields, as an alternative to the typed flags of Convert.
+type ConvertRequest struct {
+	Image string `json:"image"`
+
+	// Format is the format of the converted image, such as UDZO.
+	Format string `json:"format"`
+
+	Output string `json:"output"`
+
+	// Encryption is the encryption algorithm of the converted image, AES-128 or AES-256,
+	// and Passphrase the passphrase of the source image, or of the encrypted converted image.
+	Encryption string `json:"encryption,omitempty"`
+	Passphrase string `json:"-"`
+
+	// SegmentSize segments the converted image, in any form accepted by ConvertSegmentSize.
+	SegmentSize string `json:"segmentsize,omitempty"`
+
+	Shadow string `json:"shadow,omitempty"`
+}
+
+// Flags returns the format and the flags of r to give to Convert.
+func (r ConvertRequest) Flags() (FormatFlag, []ConvertFlag, error) {
+	format := parseFormat(strings.ToUpper(r.Format))
+	if format == 0 {
+		return nil, nil, fmt.Errorf("convert request: invalid format %q", r.Format)
+	}
+
+	var flags []ConvertFlag
+	if r.Encryption != "" {
+		e, err := parseEncryptionType(r.Encryption)
+		if err != nil {
+			return nil, nil, err
+		}
+		flags = append(flags, e)
+	}
+	if r.Passphrase != "" {
+		flags = append(flags, Passphrase(r.Passphrase))
+	}
+	if r.SegmentSize != "" {
+		flags = append(flags, ConvertSegmentSize(r.SegmentSize))
+	}
+	if r.Shadow != "" {
+		flags = append(flags, Shadow(r.Shadow))
+	}
+	return format, flags, nil
+}
+
+// Args returns the hdiutil convert arguments of r, following the verb.
+func (r ConvertRequest) Args() ([]string, error) {
+	format, flags, err := r.Flags()
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{r.Image}, format.FormatFlag()...)
+	args = append(args, "-o", r.Output)
+	for _, flag := range flags {
+		args = append(args, flag.ConvertFlag()...)
+	}
+	return args, nil
+}
+
+// Run runs r with c, or DefaultClient if c is nil, like Convert.
+func (r ConvertRequest) Run(c *Client) (ConvertResult, error) {
+	format, flags, err := r.Flags()
+	if err != nil {
+		return ConvertResult{}, err
+	}
+	if c == nil {
+		c = DefaultClient
+	}
+	return c.Convert(r.Image, format, r.Output, flags...)
+}
+
+// parseCreateType returns the image type named name, case-insensitively.
+func parseCreateType(name string) (createType, error) {
+	for t := CreateUDIF; t <= CreateSPARSEBUNDLE; t <<= 1 {
+		if strings.EqualFold(t.String(), name) {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid image type %q", name)
+}
+
+// parseCreateFS returns the filesystem named name, case-insensitively.
+func parseCreateFS(name string) (CreateFS, error) {
+	for fs := CreateHFSPlus; fs <= CreateUDF; fs <<= 1 {
+		if strings.EqualFold(fs.String(), name) {
+			return fs, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid filesystem %q", name)
+}
+
+// parseEncryptionType returns the encryption algorithm named name, case-insensitively.
+func parseEncryptionType(name string) (EncryptionType, error) {
+	for e := AES128; e <= AES256; e <<= 1 {
+		if strings.EqualFold(e.String(), name) {
+			return e, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid encryption %q", name)
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SizeSpec is a hdiutil resize size specification, returning its command-line arguments.
+type SizeSpec interface {
+	SizeSpec() []string
+}
+
+func (s Size) SizeSpec() []string       { return s.SizeFlag() }
+func (c CreateSize) SizeSpec() []string { return c.SizeFlag() }
+
+// ResizeSectors specify the new size of the image in 512-byte sectors.
+type ResizeSectors int64
+
+func (r ResizeSectors) SizeSpec() []string {
+	return stringFlag("sectors", strconv.FormatInt(int64(r), 10))
+}
+
+type resizeMin bool
+
+func (r resizeMin) SizeSpec() []string { return stringFlag("sectors", "min") }
+
+// ResizeMin resizes the image to the minimum size allowed by its filesystem, as reported by ResizeLimits.
+const ResizeMin resizeMin = true
+
+// ResizeFlag is a hdiutil resize command flag, returning its command-line arguments.
+type ResizeFlag interface {
+	ResizeFlag() []string
+}
+
+type resizeImageonly bool
+
+func (r resizeImageonly) ResizeFlag() []string { return boolFlag("imageonly", bool(r)) }
+
+type resizePartitiononly bool
+
+func (r resizePartitiononly) ResizeFlag() []string { return boolFlag("partitiononly", bool(r)) }
+
+type resizeNofinalgap bool
+
+func (r resizeNofinalgap) ResizeFlag() []string { return boolFlag("nofinalgap", bool(r)) }
+
+type resizeGrowonly bool
+
+func (r resizeGrowonly) ResizeFlag() []string { return boolFlag("growonly", bool(r)) }
+
+type resizeShrinkonly bool
+
+func (r resizeShrinkonly) ResizeFlag() []string { return boolFlag("shrinkonly", bool(r)) }
+
+// ResizePartitionNumber specify which partition to resize. The default is the last, which is the only one which can be resized with free space after it.
+type ResizePartitionNumber int
+
+func (r ResizePartitionNumber) ResizeFlag() []string { return intFlag("partitionNumber", int(r)) }
+
+const (
+	// ResizeImageonly only resize the image file, not the partition(s) and filesystems inside of it.
+	ResizeImageonly resizeImageonly = true
+
+	// ResizePartitiononly only resize a partition / filesystem in the image, not the image.
+	// It fails if the partition would have to grow beyond the end of the image.
+	ResizePartitiononly resizePartitiononly = true
+
+	// ResizeNofinalgap allow resize to entirely eliminate the trailing free partition in an APM map.
+	ResizeNofinalgap resizeNofinalgap = true
+
+	// ResizeGrowonly only allow the image to grow.
+	ResizeGrowonly resizeGrowonly = true
+
+	// ResizeShrinkonly only allow the image to shrink.
+	ResizeShrinkonly resizeShrinkonly = true
+)
+
+// Resize resize a disk image or the containers within it to size.
+//
+// Sparse images, sparse bundles and read/write UDIF images can be resized. Growing the image also grows its last partition and filesystem,
+// and shrinking fails if the filesystem cannot shrink as much, see ResizeLimits.
+func Resize(image string, size SizeSpec, flags ...ResizeFlag) error {
+	return DefaultClient.Resize(image, size, flags...)
+}
+
+// Resize is like the package-level Resize, but runs hdiutil with the configuration of c.
+func (c *Client) Resize(image string, size SizeSpec, flags ...ResizeFlag) error {
+	cmd := c.command("resize")
+	cmd.target = image
+	cmd.output = image
+	cmd.shrink = c.resizeShrinks(image, size, flags)
+	cmd.args = append(cmd.args, size.SizeSpec()...)
+	for _, flag := range flags {
+		cmd.flag(flag, flag.ResizeFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	_, _, err := c.run(cmd)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resizeShrinks reports whether resizing image to size may shrink it, which makes the resize destructive.
+//
+// It is only worked out for the Client audit writer and ConfirmFunc, as it runs hdiutil resize -limits for the current size,
+// and a shrink is assumed if the requested or the current size is not known.
+func (c *Client) resizeShrinks(image string, size SizeSpec, flags []ResizeFlag) bool {
+	if c.audit == nil && c.confirm == nil || c.dryRun {
+		return true
+	}
+	for _, flag := range flags {
+		if flag == ResizeGrowonly {
+			return false
+		}
+	}
+
+	var want Size
+	switch s := size.(type) {
+	case Size:
+		want = s
+	case CreateSize:
+		n, err := ParseSize(string(s))
+		if err != nil {
+			return true
+		}
+		want = n
+	case ResizeSectors:
+		want = Size(s * sectorSize)
+	default:
+		return true
+	}
+
+	limits, err := c.ResizeLimits(image)
+	if err != nil {
+		return true
+	}
+	return want < limits.CurrentSize()
+}
+
+// SizeLimits is the sizes an image can be resized to, in 512-byte sectors, as reported by hdiutil resize -limits.
+type SizeLimits struct {
+	Min     int64
+	Current int64
+	Max     int64
+}
+
+// MinSize, CurrentSize and MaxSize returns the limits of l in bytes.
+func (l SizeLimits) MinSize() Size     { return Size(l.Min * sectorSize) }
+func (l SizeLimits) CurrentSize() Size { return Size(l.Current * sectorSize) }
+func (l SizeLimits) MaxSize() Size     { return Size(l.Max * sectorSize) }
+
+// ResizeLimits returns the minimum, current and maximum sizes of image, without modifying it.
+func ResizeLimits(image string, flags ...ResizeFlag) (SizeLimits, error) {
+	return DefaultClient.ResizeLimits(image, flags...)
+}
+
+// ResizeLimits is like the package-level ResizeLimits, but runs hdiutil with the configuration of c.
+func (c *Client) ResizeLimits(image string, flags ...ResizeFlag) (SizeLimits, error) {
+	cmd := c.command("resize", "-limits")
+	cmd.target = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.ResizeFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return SizeLimits{}, err
+	}
+
+	return parseResizeLimits(out)
+}
+
+// parseResizeLimits parses the hdiutil resize -limits output, whose last line is the three sector counts:
+//
+//	 min 	 cur 	 max
+//	81920	409600	34359738368
+func parseResizeLimits(out []byte) (SizeLimits, error) {
+	var (
+		limits SizeLimits
+		found  bool
+	)
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		var n [3]int64
+		var err error
+		for i, f := range fields {
+			if n[i], err = strconv.ParseInt(f, 10, 64); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			limits, found = SizeLimits{Min: n[0], Current: n[1], Max: n[2]}, true
+		}
+	}
+	if !found {
+		return SizeLimits{}, fmt.Errorf("no limits in hdiutil resize output: %s", out)
+	}
+
+	return limits, nil
+}
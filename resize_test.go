@@ -0,0 +1,34 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "testing"
+
+func TestParseResizeLimits(t *testing.T) {
+	tests := []struct {
+		out  string
+		want SizeLimits
+		ok   bool
+	}{
+		{" min \t cur \t max \n81920\t409600\t34359738368\n", SizeLimits{Min: 81920, Current: 409600, Max: 34359738368}, true},
+		{"Checksumming...\n min \t cur \t max \n  1  2  3  \n", SizeLimits{Min: 1, Current: 2, Max: 3}, true},
+		{"1 2 3\n4 5 6\n", SizeLimits{Min: 4, Current: 5, Max: 6}, true},
+		{" min \t cur \t max \n", SizeLimits{}, false},
+		{"hdiutil: resize: failed\n", SizeLimits{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseResizeLimits([]byte(tt.out))
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("parseResizeLimits(%q) = %+v, %v, want %+v", tt.out, got, err, tt.want)
+		}
+	}
+}
+
+func TestSizeLimitsBytes(t *testing.T) {
+	l := SizeLimits{Min: 2, Current: 4, Max: 8}
+	if l.MinSize() != 1024 || l.CurrentSize() != 2048 || l.MaxSize() != 4096 {
+		t.Errorf("sizes %d %d %d", l.MinSize(), l.CurrentSize(), l.MaxSize())
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Runner runs hdiutil with args, the verb followed by its arguments, and stdin as the standard input.
+//
+// The returns standard output, standard error and error, which reports the exit status like *exec.ExitError,
+// with an ExitCode method, if hdiutil ran but failed.
+//
+// Every verb of a Client runs through its Runner, so that a Runner can wrap another one, such as to run hdiutil
+// with sudo or on a remote host over ssh, or fake hdiutil in tests.
+type Runner interface {
+	Run(ctx context.Context, args []string, stdin io.Reader) (stdout, stderr []byte, err error)
+}
+
+// RunnerFunc is a function implementing Runner.
+type RunnerFunc func(ctx context.Context, args []string, stdin io.Reader) (stdout, stderr []byte, err error)
+
+// Run implements Runner.
+func (f RunnerFunc) Run(ctx context.Context, args []string, stdin io.Reader) (stdout, stderr []byte, err error) {
+	return f(ctx, args, stdin)
+}
+
+// ExecRunner is the Runner executing the hdiutil binary. It is the default Runner of a Client.
+type ExecRunner struct {
+	// Path is the path of the hdiutil binary. The default is the one returned by HdiutilPath.
+	Path string
+
+	// Env is the environment of hdiutil. The default is the environment of the current process.
+	Env []string
+
+	// Sudo runs hdiutil as root with sudo -n, see WithSudo.
+	Sudo bool
+
+	// Priority is the priority hdiutil runs with, see WithPriority.
+	Priority Priority
+}
+
+// Run implements Runner.
+func (r ExecRunner) Run(ctx context.Context, args []string, stdin io.Reader) (stdout, stderr []byte, err error) {
+	return r.run(ctx, args, stdin, nil, nil)
+}
+
+// run is like Run, but also copies the standard output and standard error to the non-nil stdoutW and stderrW as hdiutil runs.
+func (r ExecRunner) run(ctx context.Context, args []string, stdin io.Reader, stdoutW, stderrW io.Writer) (stdout, stderr []byte, err error) {
+	var o, e bytes.Buffer
+	x, err := r.command(ctx, args, stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	x.Stdout, x.Stderr = teeWriter(&o, stdoutW), teeWriter(&e, stderrW)
+	err = x.Run()
+	return o.Bytes(), e.Bytes(), err
+}
+
+// teeWriter returns a writer writing to w and to tee, if it is not nil.
+// The errors of tee are ignored, so that a failing tee does not interrupt hdiutil.
+func teeWriter(w, tee io.Writer) io.Writer {
+	if tee == nil {
+		return w
+	}
+	return io.MultiWriter(w, bestEffortWriter{tee})
+}
+
+// bestEffortWriter is a writer ignoring the errors of w.
+type bestEffortWriter struct {
+	w io.Writer
+}
+
+func (b bestEffortWriter) Write(p []byte) (int, error) {
+	b.w.Write(p)
+	return len(p), nil
+}
+
+// waitDelay is how long a killed hdiutil is waited for once its context is done,
+// after which its output pipes are closed even if a helper process inherited them.
+const waitDelay = 5 * time.Second
+
+// command returns the command running hdiutil with args.
+//
+// hdiutil runs in its own process group, which is killed as a whole when ctx is done.
+func (r ExecRunner) command(ctx context.Context, args []string, stdin io.Reader) (*exec.Cmd, error) {
+	path := r.Path
+	if path == "" {
+		var err error
+		if path, err = HdiutilPath(); err != nil {
+			return nil, err
+		}
+	}
+	path, args = r.wrap(path, args)
+	x := exec.CommandContext(ctx, path, args...)
+	x.Stdin = stdin
+	x.Env = r.Env
+	x.WaitDelay = waitDelay
+	setProcessGroup(x)
+	return x, nil
+}
+
+// wrap returns the binary and arguments running the hdiutil binary path with args, through sudo and taskpolicy
+// as configured by r.
+func (r ExecRunner) wrap(path string, args []string) (string, []string) {
+	if r.Sudo {
+		args = append([]string{"-n", "--", path}, args...)
+		path = sudoPath
+	}
+	if p := r.Priority.taskpolicyArgs(); p != nil {
+		args = append(append(p, path), args...)
+		path = taskpolicyPath
+	}
+	return path, args
+}
+
+// WithRunner sets the Runner of the Client, such as a Recorder or a Replayer.
+//
+// The progress of a Runner other than ExecRunner is reported once it returns, from its output.
+func WithRunner(r Runner) ClientOption {
+	return func(c *Client) {
+		c.runner = r
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SegmentFlag is a hdiutil segment command flag, returning its command-line arguments.
+type SegmentFlag interface {
+	SegmentFlag() []string
+}
+
+// SegmentCount specify the number of segments. Only one of SegmentCount and SegmentSize can be specified.
+type SegmentCount int
+
+func (s SegmentCount) SegmentFlag() []string { return intFlag("segmentCount", int(s)) }
+
+// SegmentSize specify the segment size, in any form accepted by ParseSize or hdiutil, such as "650m".
+type SegmentSize string
+
+func (s SegmentSize) SegmentFlag() []string { return stringFlag("segmentSize", hdiutilSize(string(s))) }
+
+// SegmentFirstSize specify a different size for the first segment, such as to fit a smaller first medium.
+type SegmentFirstSize string
+
+func (s SegmentFirstSize) SegmentFlag() []string {
+	return stringFlag("firstSegmentSize", hdiutilSize(string(s)))
+}
+
+// SegmentFile is a file of a segmented image.
+type SegmentFile struct {
+	// Path is the path of the segment file.
+	Path string
+
+	// Size is the size of the segment file, in bytes.
+	Size int64
+}
+
+// Segment segment image into several files, the first being firstSegname with the .dmg extension added if it has none,
+// and the following ones named like firstSegname.002.dmgpart. The returns segment files in order and error.
+//
+// Only a read-only image can be segmented. The segmented image is attached by its first segment.
+func Segment(image, firstSegname string, flags ...SegmentFlag) ([]SegmentFile, error) {
+	return DefaultClient.Segment(image, firstSegname, flags...)
+}
+
+// Segment is like the package-level Segment, but runs hdiutil with the configuration of c.
+func (c *Client) Segment(image, firstSegname string, flags ...SegmentFlag) ([]SegmentFile, error) {
+	if filepath.Ext(firstSegname) == "" {
+		firstSegname += ".dmg"
+	}
+
+	cmd := c.command("segment", "-o", firstSegname)
+	cmd.target = image
+	cmd.output = firstSegname
+	for _, flag := range flags {
+		cmd.flag(flag, flag.SegmentFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	if _, _, err := c.run(cmd); err != nil {
+		return nil, err
+	}
+
+	return SegmentFiles(firstSegname)
+}
+
+// SegmentFiles returns the files of the segmented image whose first segment is firstSegment, in order.
+// An image which is not segmented has a single file.
+//
+// The files are checked to be numbered without gaps from .002.dmgpart, so that a missing segment is reported
+// instead of failing when the image is attached.
+func SegmentFiles(firstSegment string) ([]SegmentFile, error) {
+	fi, err := os.Stat(firstSegment)
+	if err != nil {
+		return nil, err
+	}
+	size, err := diskSize(firstSegment, fi)
+	if err != nil {
+		return nil, err
+	}
+	files := []SegmentFile{{Path: firstSegment, Size: size}}
+
+	parts := segmentParts(firstSegment)
+	for i, part := range parts {
+		want := strconv.Itoa(i + 2)
+		if n := strings.TrimLeft(segmentNumber(part), "0"); n != want {
+			return files, fmt.Errorf("segment %s of %s is missing", want, firstSegment)
+		}
+		fi, err := os.Stat(part)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, SegmentFile{Path: part, Size: fi.Size()})
+	}
+
+	return files, nil
+}
+
+// segmentParts returns the segment files following the first segment of a segmented image, sorted by number.
+func segmentParts(firstSegment string) []string {
+	base := strings.TrimSuffix(firstSegment, filepath.Ext(firstSegment))
+	parts, err := filepath.Glob(GlobLiteral(base) + ".[0-9][0-9][0-9].dmgpart")
+	if err != nil {
+		return nil
+	}
+	sort.Strings(parts)
+	return parts
+}
+
+// segmentNumber returns the number of the segment file part, such as "002" for image.002.dmgpart.
+func segmentNumber(part string) string {
+	part = strings.TrimSuffix(part, ".dmgpart")
+	return part[strings.LastIndexByte(part, '.')+1:]
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size is an image size in bytes. It implements the create size flag, rendered in the hdiutil size syntax.
+type Size int64
+
+// sectorSize is the size of the sectors counted by the "b" and "s" suffixes.
+const sectorSize = 512
+
+// sizeUnits is the multipliers of the size suffixes, lower cased.
+// The single letter suffixes are binary as in hdiutil, "b" and "s" count 512-byte sectors.
+var sizeUnits = map[string]float64{
+	"":  1,
+	"b": sectorSize,
+	"s": sectorSize,
+	"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+	"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+	"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	"t": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+	"p": 1 << 50, "pib": 1 << 50, "pb": 1e15,
+	"e": 1 << 60, "eib": 1 << 60, "eb": 1e18,
+}
+
+// ParseSize parses s as an image size.
+//
+// s is a decimal number, possibly fractional, followed by an optional unit:
+//
+//	k, m, g, t, p, e            binary multiples, as in hdiutil: "10g" is 10 GiB
+//	KiB, MiB, GiB, TiB, ...     binary multiples: "1.5GiB"
+//	KB, MB, GB, TB, ...         decimal multiples: "500MB" is 500,000,000 bytes
+//	b, s                        512-byte sectors, as in hdiutil: "1048576s"
+//	B or none                   bytes
+//
+// The units are case-insensitive, except "B" which is bytes while "b" is sectors as in hdiutil.
+func ParseSize(s string) (Size, error) {
+	s = strings.TrimSpace(s)
+	i := strings.LastIndexAny(s, "0123456789.") + 1
+	num, unit := s[:i], strings.TrimSpace(s[i:])
+
+	mul, ok := sizeUnits[strings.ToLower(unit)]
+	if unit == "B" {
+		mul, ok = 1, true
+	}
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, unit)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	bytes := math.Round(f * mul)
+	// math.MaxInt64 converts to the float64 1<<63, which itself overflows int64.
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: out of range", s)
+	}
+
+	return Size(bytes), nil
+}
+
+// String returns s in the hdiutil size syntax, using the largest binary unit which divides s exactly,
+// such as "1536m" for 1.5 GiB. A size which is not a whole number of sectors is rounded up to the next sector.
+func (s Size) String() string {
+	for _, u := range []string{"e", "p", "t", "g", "m", "k"} {
+		m := int64(sizeUnits[u])
+		if s != 0 && int64(s)%m == 0 {
+			return strconv.FormatInt(int64(s)/m, 10) + u
+		}
+	}
+	return strconv.FormatInt((int64(s)+sectorSize-1)/sectorSize, 10) + "b"
+}
+
+func (s Size) SizeFlag() []string { return stringFlag("size", s.String()) }
+
+// hdiutilSize returns the size s in the hdiutil size syntax if it parses with ParseSize, or s unchanged.
+func hdiutilSize(s string) string {
+	size, err := ParseSize(s)
+	if err != nil {
+		return s
+	}
+	return size.String()
+}
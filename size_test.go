@@ -0,0 +1,88 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Size
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1b", 512},
+		{"2s", 1024},
+		{"1048576S", 512 << 20},
+		{"1k", 1 << 10},
+		{"1KiB", 1 << 10},
+		{"1KB", 1000},
+		{"10m", 10 << 20},
+		{"10MiB", 10 << 20},
+		{"500MB", 500e6},
+		{"1.5g", 3 << 29},
+		{"1.5GiB", 3 << 29},
+		{"2GB", 2e9},
+		{"1t", 1 << 40},
+		{"1TiB", 1 << 40},
+		{"1TB", 1e12},
+		{"1p", 1 << 50},
+		{"1PiB", 1 << 50},
+		{"1PB", 1e15},
+		{"1e", 1 << 60},
+		{"1EiB", 1 << 60},
+		{"1EB", 1e18},
+		{"7e", 7 << 60},
+		{" 100 m ", 100 << 20},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.s)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseSizeErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"m",
+		"-1",
+		"10x",
+		"1.2.3g",
+		"8e",                  // 2^63
+		"9223372036854775808", // 2^63
+		"9.3EB",
+		"16EiB",
+	} {
+		if got, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want error", s, got)
+		}
+	}
+}
+
+func TestSizeString(t *testing.T) {
+	tests := []struct {
+		s    Size
+		want string
+	}{
+		{0, "0b"},
+		{1, "1b"},
+		{512, "1b"},
+		{513, "2b"},
+		{1 << 10, "1k"},
+		{3 << 29, "1536m"},
+		{10 << 30, "10g"},
+		{1 << 40, "1t"},
+		{1 << 50, "1p"},
+		{1 << 60, "1e"},
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("Size(%d).String() = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
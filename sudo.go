@@ -0,0 +1,54 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrSudoAuth is the error of the invocations run with WithSudo which sudo refused to run,
+// such as when a password would be required or the user is not allowed to run hdiutil.
+var ErrSudoAuth = errors.New("sudo authentication failed")
+
+// sudoPath is the path of the sudo binary.
+const sudoPath = "/usr/bin/sudo"
+
+// WithSudo runs hdiutil as root with sudo(8), for the flags which need root privileges,
+// such as AttachNotRemovable, or AttachOwnersOn to honor the ownership of the files of the image.
+// The helper tools which need root privileges, such as the createinstallmedia tool of CreateInstallMedia, also run with sudo.
+//
+// sudo is run non-interactively with -n, so the current user must be allowed to run hdiutil without a password,
+// such as by a NOPASSWD sudoers rule, else the verbs fail with ErrSudoAuth. The environment of hdiutil, including
+// the variables of WithEnv and WithTempDir, is subject to the env_reset policy of sudoers.
+// As hdiutil then runs as another user, it is not killed on cancellation, see WithContext, unless sudo relays the signal.
+//
+// It is ignored if the Client has a Runner set by WithRunner, and for the helper tools, by WithToolRunner.
+func WithSudo() ClientOption {
+	return func(c *Client) {
+		c.sudo = true
+	}
+}
+
+// sudoAuthMessages is the messages of sudo -n when it refuses to run a command.
+var sudoAuthMessages = [][]byte{
+	[]byte("a password is required"),
+	[]byte("is not in the sudoers file"),
+	[]byte("is not allowed to execute"),
+	[]byte("a terminal is required"),
+}
+
+// isSudoAuthFailure reports whether the standard error stderr of a failed sudo invocation is the one of sudo refusing to run it.
+func isSudoAuthFailure(stderr []byte) bool {
+	if !bytes.HasPrefix(stderr, []byte("sudo:")) {
+		return false
+	}
+	for _, msg := range sudoAuthMessages {
+		if bytes.Contains(stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
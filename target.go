@@ -0,0 +1,74 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotAttached is returned when a target does not resolve to an attached image.
+var ErrNotAttached = errors.New("not an attached image")
+
+// ResolveTarget returns the device node of target, which may be any of
+//
+//	/dev/disk2, /dev/disk2s1  a block device node, returned as is
+//	/dev/rdisk2s1, disk2s1    a raw or short device node, returned as the block device node
+//	/Volumes/Foo              a mount point of an attached image
+//	/path/to/foo.dmg          the path of an attached image, resolved to its whole disk
+//
+// The mount points and image paths are resolved with Info, and ErrNotAttached is returned if no attached image matches.
+func ResolveTarget(target string) (DeviceNode, error) {
+	return DefaultClient.ResolveTarget(target)
+}
+
+// ResolveTarget is like the package-level ResolveTarget, but runs hdiutil with the configuration of c.
+func (c *Client) ResolveTarget(target string) (DeviceNode, error) {
+	if diskNodeRe.MatchString(target) {
+		return DeviceNode(blockDeviceNode(target)), nil
+	}
+
+	info, err := c.Info()
+	if err != nil {
+		return "", err
+	}
+	clean := filepath.Clean(target)
+	for _, img := range info.Images {
+		for _, e := range img.SystemEntities {
+			if e.MountPoint != "" && filepath.Clean(e.MountPoint) == clean {
+				return DeviceNode(e.DevEntry), nil
+			}
+		}
+	}
+	for _, img := range info.Images {
+		if sameFile(target, img.ImagePath) {
+			if dev := img.DeviceNode(); dev != "" {
+				return dev, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%s: %w", target, ErrNotAttached)
+}
+
+// detachTarget returns the device node of target for Detach and Eject, resolved like ResolveTarget.
+//
+// The targets under /dev and the bare device names are not looked up with Info, and no target is looked up in dry-run mode,
+// so that the dry-run command line is the one of the verb rather than of info.
+func (c *Client) detachTarget(target string) (string, error) {
+	switch {
+	case diskNodeRe.MatchString(target):
+		return blockDeviceNode(target), nil
+	case c.dryRun, strings.HasPrefix(target, "/dev/"):
+		return target, nil
+	}
+	dev, err := c.ResolveTarget(target)
+	if err != nil {
+		return "", err
+	}
+	return dev.String(), nil
+}
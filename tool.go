@@ -0,0 +1,87 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithToolRunner sets the Runner of the helper tools the Client runs besides hdiutil, such as diskutil, drutil, codesign, bless and xattr.
+//
+// The args given to r start with the name of the tool instead of an hdiutil verb, such as "diskutil", "apfs", "list",
+// or its path for the tools outside the PATH directories.
+// The default runs the tool found in the PATH directories with the environment of the Client,
+// and with sudo for the tools needing root privileges if the Client uses WithSudo.
+// The Runner set by WithRunner does not run the helper tools, so that a fake hdiutil does not receive them.
+func WithToolRunner(r Runner) ClientOption {
+	return func(c *Client) {
+		c.toolRunner = r
+	}
+}
+
+// toolCommand returns a new invocation of the helper tool name with args, to be run with runTool.
+// The name of the tool stands for the verb of the invocation, such as in the logs and the Policy DenyVerbs.
+func (c *Client) toolCommand(name string, args ...string) *command {
+	return &command{verb: name, args: args, tool: true}
+}
+
+// runTool runs the helper tool invocation cmd and returns its standard output and standard error.
+//
+// Like the hdiutil verbs, the invocation is checked against the Policy and the read-only mode, only built in dry-run mode,
+// confirmed and audited if destructive, logged, bounded by the timeout of its call or the Client default timeout,
+// and its failure returned as an *Error.
+func (c *Client) runTool(cmd *command) (stdout, stderr []byte, err error) {
+	start := time.Now()
+	defer func() {
+		c.logInvocation(cmd, start, stdout, stderr, err)
+		c.auditInvocation(cmd, start, err)
+	}()
+
+	if err := c.enforcePolicy(cmd); err != nil {
+		return nil, nil, err
+	}
+	if err := c.enforceReadOnly(cmd); err != nil {
+		return nil, nil, err
+	}
+	if c.dryRun {
+		return nil, nil, &DryRunError{Args: append([]string{cmd.verb}, cmd.args...)}
+	}
+	if err := c.confirmInvocation(cmd); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := c.context(&cmd.call)
+	defer cancel()
+	if d := c.timeout(cmd.verb, &cmd.call); d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	sudo := cmd.sudo && c.sudo && c.toolRunner == nil
+	if c.toolRunner != nil {
+		stdout, stderr, err = c.toolRunner.Run(ctx, append([]string{cmd.verb}, cmd.args...), cmd.stdin)
+	} else {
+		stdout, stderr, err = ExecRunner{Path: cmd.verb, Env: c.env(), Sudo: sudo}.Run(ctx, cmd.args, cmd.stdin)
+	}
+	if err != nil && sudo && isSudoAuthFailure(stderr) {
+		err = fmt.Errorf("%w: %w", ErrSudoAuth, err)
+	}
+	if err != nil && ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: %w: %w", ErrTimeout, ctx.Err(), err)
+		} else {
+			err = fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+	if err != nil {
+		err = newError(cmd, stdout, stderr, err)
+	}
+
+	return stdout, stderr, err
+}
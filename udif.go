@@ -0,0 +1,72 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNotUDIF is returned when an image is not a UDIF image, such as a sparse image or an ISO.
+var ErrNotUDIF = errors.New("not a UDIF image")
+
+// kolySize is the size of the UDIF trailer, at the end of the data fork.
+const kolySize = 512
+
+// koly is the part of the UDIF trailer used by this package. All fields are big-endian.
+type koly struct {
+	RsrcForkOffset uint64 // offset 40
+	RsrcForkLength uint64 // offset 48
+	XMLOffset      uint64 // offset 216
+	XMLLength      uint64 // offset 224
+}
+
+// readKoly reads the UDIF trailer of the image file at path.
+func readKoly(path string) (*koly, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() || fi.Size() < kolySize {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotUDIF)
+	}
+
+	b := make([]byte, kolySize)
+	if _, err := f.ReadAt(b, fi.Size()-kolySize); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if string(b[:4]) != "koly" {
+		return nil, fmt.Errorf("%s: %w", path, ErrNotUDIF)
+	}
+
+	be := binary.BigEndian
+	return &koly{
+		RsrcForkOffset: be.Uint64(b[40:]),
+		RsrcForkLength: be.Uint64(b[48:]),
+		XMLOffset:      be.Uint64(b[216:]),
+		XMLLength:      be.Uint64(b[224:]),
+	}, nil
+}
+
+// IsFlattened reports whether the UDIF image has its resources embedded in the data fork, as written by flatten,
+// rather than in a resource fork only, as after unflatten.
+//
+// It reads the UDIF trailer of the image without running hdiutil. ErrNotUDIF is returned for the other image formats.
+func IsFlattened(image string) (bool, error) {
+	k, err := readKoly(image)
+	if err != nil {
+		return false, err
+	}
+	return k.XMLLength > 0, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// UdifderezFlag is a hdiutil udifderez command flag, returning its command-line arguments.
+type UdifderezFlag interface {
+	UdifderezFlag() []string
+}
+
+// Resources is the resources embedded in a UDIF image, by resource type, such as "LPic", "STR#" or "TEXT" for a software license agreement.
+type Resources map[string][]Resource
+
+// Resource is a resource embedded in a UDIF image.
+type Resource struct {
+	ID   int
+	Name string
+
+	// Attributes is the resource attributes, such as 0x0050.
+	Attributes string
+
+	Data []byte
+}
+
+// resourcePlist is a resource of the udifderez -xml output, whose ID is a string.
+type resourcePlist struct {
+	ID         string `plist:"ID"`
+	Name       string `plist:"Name"`
+	Attributes string `plist:"Attributes"`
+	Data       []byte `plist:"Data"`
+}
+
+// Udifderez extract the resources of the UDIF image, returning them as the XML property list written by hdiutil udifderez -xml.
+//
+// The property list can be given to Udifrez as is, or decoded with ParseResources to inspect or modify the resources.
+func Udifderez(image string, flags ...UdifderezFlag) ([]byte, error) {
+	return DefaultClient.Udifderez(image, flags...)
+}
+
+// Udifderez is like the package-level Udifderez, but runs hdiutil with the configuration of c.
+func (c *Client) Udifderez(image string, flags ...UdifderezFlag) ([]byte, error) {
+	cmd := c.command("udifderez", "-xml")
+	cmd.target = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.UdifderezFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	out, _, err := c.run(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawPlist(out), nil
+}
+
+// ImageResources returns the decoded resources of the UDIF image.
+func ImageResources(image string, flags ...UdifderezFlag) (Resources, error) {
+	return DefaultClient.ImageResources(image, flags...)
+}
+
+// ImageResources is like the package-level ImageResources, but runs hdiutil with the configuration of c.
+func (c *Client) ImageResources(image string, flags ...UdifderezFlag) (Resources, error) {
+	data, err := c.Udifderez(image, flags...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseResources(data)
+}
+
+// ParseResources decodes the hdiutil udifderez -xml output data.
+func ParseResources(data []byte) (Resources, error) {
+	var raw map[string][]resourcePlist
+	if err := unmarshalPlist(data, &raw); err != nil {
+		return nil, err
+	}
+
+	res := make(Resources, len(raw))
+	for typ, list := range raw {
+		for _, r := range list {
+			id, err := strconv.Atoi(r.ID)
+			if err != nil {
+				return nil, fmt.Errorf("resource %s: invalid ID %q", typ, r.ID)
+			}
+			res[typ] = append(res[typ], Resource{ID: id, Name: r.Name, Attributes: r.Attributes, Data: r.Data})
+		}
+	}
+
+	return res, nil
+}
+
+// Marshal returns the XML property list encoding of r, the format read by Udifrez.
+func (r Resources) Marshal() ([]byte, error) {
+	raw := make(map[string][]resourcePlist, len(r))
+	for typ, list := range r {
+		for _, res := range list {
+			attrs := res.Attributes
+			if attrs == "" {
+				attrs = "0x0000"
+			}
+			raw[typ] = append(raw[typ], resourcePlist{ID: strconv.Itoa(res.ID), Name: res.Name, Attributes: attrs, Data: res.Data})
+		}
+	}
+	return marshalPlist(raw)
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// UdifrezFlag is a hdiutil udifrez command flag, returning its command-line arguments.
+type UdifrezFlag interface {
+	UdifrezFlag() []string
+}
+
+// Udifrez embed the resources of rezFile into the UDIF image, such as a software license agreement (SLA) shown when the image is attached.
+//
+// rezFile is an XML property list of resources, passed with -xml, such as returned by Udifderez or Resources.Marshal.
+// Resource files in the Rez source format must be converted first. The image is modified in place.
+func Udifrez(image, rezFile string, flags ...UdifrezFlag) error {
+	return DefaultClient.Udifrez(image, rezFile, flags...)
+}
+
+// Udifrez is like the package-level Udifrez, but runs hdiutil with the configuration of c.
+func (c *Client) Udifrez(image, rezFile string, flags ...UdifrezFlag) error {
+	cmd := c.command("udifrez", "-xml", rezFile)
+	cmd.target = image
+	cmd.output = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.UdifrezFlag())
+	}
+	cmd.args = append(cmd.args, image)
+
+	if _, _, err := c.run(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
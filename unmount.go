@@ -0,0 +1,33 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+// UnmountFlag is a hdiutil unmount command flag, returning its command-line arguments.
+type UnmountFlag interface {
+	UnmountFlag() []string
+}
+
+// Unmount unmount a single mounted volume, given its mount point or device node, without detaching the image.
+//
+// Unlike Detach, the device stays attached, so the image can still be accessed at the block level, such as by Compare or a raw copy.
+func Unmount(mountPointOrDev string, flags ...UnmountFlag) error {
+	return DefaultClient.Unmount(mountPointOrDev, flags...)
+}
+
+// Unmount is like the package-level Unmount, but runs hdiutil with the configuration of c.
+func (c *Client) Unmount(mountPointOrDev string, flags ...UnmountFlag) error {
+	cmd := c.command("unmount")
+	cmd.target = mountPointOrDev
+	for _, flag := range flags {
+		cmd.flag(flag, flag.UnmountFlag())
+	}
+	cmd.args = append(cmd.args, mountPointOrDev)
+
+	if _, _, err := c.run(cmd); err != nil {
+		return err
+	}
+
+	return nil
+}
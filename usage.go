@@ -0,0 +1,90 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// VolumeUsage is the space and inode usage of a mounted volume.
+type VolumeUsage struct {
+	// Total, Free and Used is the volume size, free and used space in bytes.
+	Total uint64
+	Free  uint64
+	Used  uint64
+
+	// Available is the free space available to non-root users in bytes.
+	Available uint64
+
+	// Files and FreeFiles is the total and free inode counts.
+	Files     uint64
+	FreeFiles uint64
+}
+
+// UsedFraction returns the used fraction of the volume space, between 0 and 1.
+func (u VolumeUsage) UsedFraction() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Total)
+}
+
+// Usage returns the usage of the volume mounted at mountPoint, from statfs(2).
+func Usage(mountPoint string) (VolumeUsage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(mountPoint, &st); err != nil {
+		return VolumeUsage{}, fmt.Errorf("statfs %s: %w", mountPoint, err)
+	}
+
+	bsize := uint64(st.Bsize)
+	u := VolumeUsage{
+		Total:     uint64(st.Blocks) * bsize,
+		Free:      uint64(st.Bfree) * bsize,
+		Available: uint64(st.Bavail) * bsize,
+		Files:     uint64(st.Files),
+		FreeFiles: uint64(st.Ffree),
+	}
+	u.Used = u.Total - u.Free
+
+	return u, nil
+}
+
+// ErrNotMounted is returned by the Usage methods for a volume without mount point.
+var ErrNotMounted = errors.New("volume is not mounted")
+
+// Usage returns the usage of the volume of e, which must be mounted.
+func (e SystemEntity) Usage() (VolumeUsage, error) {
+	if e.MountPoint == "" {
+		return VolumeUsage{}, fmt.Errorf("%s: %w", e.DevEntry, ErrNotMounted)
+	}
+	return Usage(e.MountPoint)
+}
+
+// Usage returns the usage of the mounted volumes of the attached image, keyed by mount point.
+func (r AttachResult) Usage() (map[string]VolumeUsage, error) {
+	return entitiesUsage(r.SystemEntities)
+}
+
+// Usage returns the usage of the mounted volumes of img, keyed by mount point.
+func (img InfoImage) Usage() (map[string]VolumeUsage, error) {
+	return entitiesUsage(img.SystemEntities)
+}
+
+func entitiesUsage(entities []SystemEntity) (map[string]VolumeUsage, error) {
+	usage := make(map[string]VolumeUsage)
+	for _, e := range entities {
+		if e.MountPoint == "" {
+			continue
+		}
+		u, err := e.Usage()
+		if err != nil {
+			return nil, err
+		}
+		usage[e.MountPoint] = u
+	}
+	return usage, nil
+}
@@ -4,38 +4,87 @@
 
 package hdiutil
 
-import "os/exec"
+import (
+	"regexp"
+	"time"
+)
 
-// verifyFlag implements a hdiutil verify command flag interface.
-type verifyFlag interface {
-	verifyFlag() []string
+// VerifyFlag is a hdiutil verify command flag, returning its command-line arguments.
+type VerifyFlag interface {
+	VerifyFlag() []string
 }
 
-type verifyCache bool
+// VerifyResult is the outcome of the verification of an image by Verify or VerifyAll.
+type VerifyResult struct {
+	// Image is the verified image.
+	Image string
 
-func (v verifyCache) verifyFlag() []string { return boolFlag("cache", bool(v)) }
+	// Err is the verification error, or nil if the checksum of Image is valid.
+	Err error
 
-const (
-	// VerifyCache do cache checksum-verification.
-	VerifyCache verifyCache = true
+	// Elapsed is the duration of the verification.
+	Elapsed time.Duration
 
-	// VerifyNoCache do not cache checksum-verification cache.
-	VerifyNoCache verifyCache = false
-)
+	// Computed is the checksum computed from the image data, and Stored the one recorded in the image.
+	// They are zero if hdiutil did not report them, such as for an image without a checksum.
+	Computed ChecksumValue
+	Stored   ChecksumValue
+}
 
-// Verify compute the checksum of a "read-only" or "compressed" image and verify it against the value stored in the image.
-func Verify(image string, flags ...verifyFlag) error {
-	cmd := exec.Command(hdiutilPath, "verify", image)
-	if len(flags) > 0 {
-		for _, flag := range flags {
-			cmd.Args = append(cmd.Args, flag.verifyFlag()...)
+// OK reports whether the image was verified successfully.
+func (r VerifyResult) OK() bool { return r.Err == nil }
+
+// verifiedRe matches the checksum lines of the hdiutil verify output, such as
+//
+//	calculated CRC32 $5B1A3B4C
+//	verified   CRC32 $5B1A3B4C
+var verifiedRe = regexp.MustCompile(`(?m)^\s*(calculated|verified)\s+(\S+)\s+\$?([0-9A-Fa-f]+)\s*$`)
+
+// setChecksums records in r the checksums reported in the verify output out.
+// A verified checksum matches the stored one, so it is recorded as both.
+func (r *VerifyResult) setChecksums(out []byte) {
+	for _, m := range verifiedRe.FindAllSubmatch(out, -1) {
+		sum, err := ParseChecksumValue(udifChecksumType(string(m[2])), string(m[3]))
+		if err != nil {
+			continue
+		}
+		r.Computed = sum
+		if string(m[1]) == "verified" {
+			r.Stored = sum
 		}
 	}
+}
+
+// Verify compute the checksum of a "read-only" or "compressed" image and verify it against the value stored in the image.
+// The returns checksums and duration of the verification, and error.
+//
+// If the verification fails, the stored checksum is read with StoredChecksum, so that it can be compared with the computed one.
+func Verify(image string, flags ...VerifyFlag) (VerifyResult, error) {
+	return DefaultClient.Verify(image, flags...)
+}
 
-	err := cmd.Run()
+// Verify is like the package-level Verify, but runs hdiutil with the configuration of c.
+func (c *Client) Verify(image string, flags ...VerifyFlag) (VerifyResult, error) {
+	cmd := c.command("verify", image)
+	cmd.target = image
+	for _, flag := range flags {
+		cmd.flag(flag, flag.VerifyFlag())
+	}
+
+	result := VerifyResult{Image: image}
+	start := time.Now()
+	stdout, stderr, err := c.run(cmd)
+	result.Elapsed = time.Since(start)
+	result.setChecksums(append(stdout, stderr...))
 	if err != nil {
-		return err
+		result.Err = err
+		if result.Stored.IsZero() {
+			if stored, err := c.StoredChecksum(image); err == nil {
+				result.Stored = stored
+			}
+		}
+		return result, result.Err
 	}
 
-	return nil
+	return result, nil
 }
@@ -0,0 +1,186 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// VerifyProgress is the aggregated progress of a VerifyAllProgress run, reported each time one of the images progresses.
+type VerifyProgress struct {
+	// Image is the image whose progress changed, and Progress is its progress report.
+	Image    string
+	Progress Progress
+
+	// Done is the number of images whose verification is finished, out of Total.
+	Done, Total int
+
+	// Percent is the completion percentage of the whole run.
+	Percent float64
+}
+
+// VerifyAll verifies images, running at most concurrency hdiutil verify at once.
+//
+// The results are in the order of images. Once ctx is done, no more image is started,
+// the running verifications are killed and the remaining images fail with the error of ctx.
+// VerifySummary reduces the results to an error.
+func VerifyAll(ctx context.Context, images []string, concurrency int, flags ...VerifyFlag) []VerifyResult {
+	return DefaultClient.VerifyAll(ctx, images, concurrency, flags...)
+}
+
+// VerifyAll is like the package-level VerifyAll, but runs hdiutil with the configuration of c.
+func (c *Client) VerifyAll(ctx context.Context, images []string, concurrency int, flags ...VerifyFlag) []VerifyResult {
+	return c.VerifyAllProgress(ctx, images, concurrency, nil, flags...)
+}
+
+// VerifyAllProgress is like VerifyAll, but reports the aggregated progress of the verifications to progress.
+// progress is called from a single goroutine at a time.
+func VerifyAllProgress(ctx context.Context, images []string, concurrency int, progress func(VerifyProgress), flags ...VerifyFlag) []VerifyResult {
+	return DefaultClient.VerifyAllProgress(ctx, images, concurrency, progress, flags...)
+}
+
+// VerifyAllProgress is like the package-level VerifyAllProgress, but runs hdiutil with the configuration of c.
+func (c *Client) VerifyAllProgress(ctx context.Context, images []string, concurrency int, progress func(VerifyProgress), flags ...VerifyFlag) []VerifyResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]VerifyResult, len(images))
+	agg := &verifyAggregate{
+		progress: progress,
+		percent:  make([]float64, len(images)),
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, image := range images {
+		results[i].Image = image
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			agg.finish(i, image)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, image string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			out, err := c.verifyProgress(ctx, image, func(p Progress) { agg.update(i, image, p) }, flags)
+			results[i].Err = err
+			results[i].Elapsed = time.Since(start)
+			results[i].setChecksums(out)
+			agg.finish(i, image)
+		}(i, image)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// verifyProgress verifies image with the context ctx, streaming its progress to progress.
+// The returns last lines of the output and error.
+func (c *Client) verifyProgress(ctx context.Context, image string, progress func(Progress), flags []VerifyFlag) ([]byte, error) {
+	cmd := c.command("verify", image)
+	cmd.target = image
+	cmd.args = append(cmd.args, Puppetstrings.VerifyFlag()...)
+	for _, flag := range flags {
+		if flag == Puppetstrings {
+			continue
+		}
+		cmd.flag(flag, flag.VerifyFlag())
+	}
+	cmd.progress = progress
+
+	// a WithContext among flags must not detach the verification from ctx, which is done once VerifyAll is canceled.
+	if callCtx := cmd.call.ctx; callCtx != nil {
+		combined, cancel := context.WithCancelCause(callCtx)
+		stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+		defer func() {
+			stop()
+			cancel(nil)
+		}()
+		ctx = combined
+	}
+	cmd.call.ctx = ctx
+
+	_, out, err := c.run(cmd)
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+// verifyAggregate aggregates the progress of the images of a VerifyAllProgress run.
+type verifyAggregate struct {
+	mu       sync.Mutex
+	progress func(VerifyProgress)
+	percent  []float64
+	done     int
+}
+
+func (a *verifyAggregate) update(i int, image string, p Progress) {
+	if a.progress == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p.Message == "" && p.Percent >= 0 {
+		a.percent[i] = p.Percent
+	}
+	a.report(image, p)
+}
+
+func (a *verifyAggregate) finish(i int, image string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.percent[i] = 100
+	a.done++
+	if a.progress != nil {
+		a.report(image, Progress{Percent: 100})
+	}
+}
+
+// report calls the progress function with the mutex of a held.
+func (a *verifyAggregate) report(image string, p Progress) {
+	var sum float64
+	for _, pct := range a.percent {
+		sum += pct
+	}
+	total := len(a.percent)
+	a.progress(VerifyProgress{
+		Image:    image,
+		Progress: p,
+		Done:     a.done,
+		Total:    total,
+		Percent:  sum / float64(total),
+	})
+}
+
+// VerifySummary returns nil if every image of results was verified, or the errors of the failed images joined,
+// each prefixed with the image path.
+func VerifySummary(results []VerifyResult) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Image, r.Err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d images failed verification: %w", len(errs), len(results), errors.Join(errs...))
+}
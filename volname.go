@@ -0,0 +1,140 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// maxLabelLen is the maximum length of FAT32 and ExFAT volume labels, in bytes for FAT32 and in UTF-16 code units for ExFAT.
+const maxLabelLen = 11
+
+// fatLabelForbidden is the characters not allowed in FAT32 volume labels.
+const fatLabelForbidden = `"*+,./:;<=>?[\]|`
+
+// exFATLabelForbidden is the characters not allowed in ExFAT volume labels.
+const exFATLabelForbidden = `"*/:<>?\|`
+
+// VolumeLabelError is returned by Create when the CreateVolname is not a valid label for the FAT32 or ExFAT filesystem.
+type VolumeLabelError struct {
+	FS     string
+	Label  string
+	Reason string
+}
+
+func (e *VolumeLabelError) Error() string {
+	return fmt.Sprintf("invalid %s volume label %q: %s", e.FS, e.Label, e.Reason)
+}
+
+type createNormalizeLabel bool
+
+func (c createNormalizeLabel) CreateFlag() []string { return nil }
+
+// CreateNormalizeLabel makes Create normalize an invalid FAT32 or ExFAT volume name with NormalizeVolumeLabel instead of returning a VolumeLabelError.
+const CreateNormalizeLabel createNormalizeLabel = true
+
+// ValidateVolumeLabel checks label against the volume label constraints of fs, which newfs_msdos and newfs_exfat report with obscure messages.
+//
+// FAT32 labels are at most 11 bytes of uppercase ASCII, without any of the characters "*+,./:;<=>?[\]|.
+// ExFAT labels are at most 11 UTF-16 code units, without control characters nor any of "*/:<>?\|.
+// The labels of the other filesystems are not checked.
+func ValidateVolumeLabel(fs CreateFS, label string) error {
+	switch fs {
+	case CreateFAT32:
+		if len(label) > maxLabelLen {
+			return &VolumeLabelError{FS: fs.String(), Label: label, Reason: fmt.Sprintf("longer than %d bytes", maxLabelLen)}
+		}
+		for _, r := range label {
+			switch {
+			case r < 0x20 || r > 0x7e:
+				return &VolumeLabelError{FS: fs.String(), Label: label, Reason: fmt.Sprintf("character %q is not printable ASCII", r)}
+			case strings.ContainsRune(fatLabelForbidden, r):
+				return &VolumeLabelError{FS: fs.String(), Label: label, Reason: fmt.Sprintf("character %q is not allowed", r)}
+			case unicode.IsLower(r):
+				return &VolumeLabelError{FS: fs.String(), Label: label, Reason: "lowercase letters are not allowed"}
+			}
+		}
+	case CreateExFAT:
+		if n := len(utf16.Encode([]rune(label))); n > maxLabelLen {
+			return &VolumeLabelError{FS: fs.String(), Label: label, Reason: fmt.Sprintf("longer than %d UTF-16 characters", maxLabelLen)}
+		}
+		for _, r := range label {
+			if unicode.IsControl(r) || strings.ContainsRune(exFATLabelForbidden, r) {
+				return &VolumeLabelError{FS: fs.String(), Label: label, Reason: fmt.Sprintf("character %q is not allowed", r)}
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeVolumeLabel returns label made valid for fs: the forbidden characters are replaced with "_",
+// FAT32 labels are uppercased, and labels are truncated to their maximum length.
+func NormalizeVolumeLabel(fs CreateFS, label string) string {
+	switch fs {
+	case CreateFAT32:
+		var b strings.Builder
+		for _, r := range strings.ToUpper(label) {
+			if b.Len() == maxLabelLen {
+				break
+			}
+			if r < 0x20 || r > 0x7e || strings.ContainsRune(fatLabelForbidden, r) {
+				r = '_'
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	case CreateExFAT:
+		var (
+			b strings.Builder
+			n int
+		)
+		for _, r := range label {
+			if unicode.IsControl(r) || strings.ContainsRune(exFATLabelForbidden, r) {
+				r = '_'
+			}
+			if n += len(utf16.Encode([]rune{r})); n > maxLabelLen {
+				break
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+	return label
+}
+
+// checkVolumeLabel validates the CreateVolname among the create flags against the CreateFS, normalizing it if CreateNormalizeLabel is given.
+func checkVolumeLabel(flags []CreateFlag) ([]CreateFlag, error) {
+	var (
+		fs        CreateFS
+		normalize bool
+		volname   = -1
+	)
+	for i, flag := range flags {
+		switch f := flag.(type) {
+		case CreateFS:
+			fs = f
+		case CreateVolname:
+			volname = i
+		case createNormalizeLabel:
+			normalize = bool(f)
+		}
+	}
+	if volname < 0 {
+		return flags, nil
+	}
+
+	label := string(flags[volname].(CreateVolname))
+	err := ValidateVolumeLabel(fs, label)
+	if err == nil || !normalize {
+		return flags, err
+	}
+
+	flags = append([]CreateFlag(nil), flags...)
+	flags[volname] = CreateVolname(NormalizeVolumeLabel(fs, label))
+	return flags, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 The go-darwin Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdiutil
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateVolumeLabel(t *testing.T) {
+	tests := []struct {
+		fs    CreateFS
+		label string
+		ok    bool
+	}{
+		{CreateFAT32, "BACKUP_2017", true},
+		{CreateFAT32, "Backup", false},
+		{CreateFAT32, "TWELVE_CHARS", false},
+		{CreateFAT32, "A.B", false},
+		{CreateFAT32, "CAFÉ", false},
+		{CreateExFAT, "Café Backup", true},
+		{CreateExFAT, "Twelve chars", false},
+		{CreateExFAT, "a/b", false},
+		{CreateExFAT, "tab\there", false},
+		{CreateAPFS, "Any label: even long ones", true},
+	}
+	for _, tt := range tests {
+		err := ValidateVolumeLabel(tt.fs, tt.label)
+		var lerr *VolumeLabelError
+		if tt.ok && err != nil || !tt.ok && !errors.As(err, &lerr) {
+			t.Errorf("ValidateVolumeLabel(%s, %q) = %v, want ok %v", tt.fs, tt.label, err, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeVolumeLabel(t *testing.T) {
+	tests := []struct {
+		fs          CreateFS
+		label, want string
+	}{
+		{CreateFAT32, "Backup", "BACKUP"},
+		{CreateFAT32, "my.backup:2017", "MY_BACKUP_2"},
+		{CreateFAT32, "Café", "CAF_"},
+		{CreateExFAT, "a/b\\c", "a_b_c"},
+		{CreateExFAT, "Twelve chars", "Twelve char"},
+		{CreateExFAT, "ab\U0001F600\U0001F600\U0001F600\U0001F600\U0001F600", "ab\U0001F600\U0001F600\U0001F600\U0001F600"},
+		{CreateAPFS, "Any: label", "Any: label"},
+	}
+	for _, tt := range tests {
+		got := NormalizeVolumeLabel(tt.fs, tt.label)
+		if got != tt.want {
+			t.Errorf("NormalizeVolumeLabel(%s, %q) = %q, want %q", tt.fs, tt.label, got, tt.want)
+		}
+		if err := ValidateVolumeLabel(tt.fs, got); err != nil {
+			t.Errorf("normalized label %q: %v", got, err)
+		}
+	}
+}
+
+func TestCheckVolumeLabel(t *testing.T) {
+	if _, err := checkVolumeLabel([]CreateFlag{CreateFAT32, CreateVolname("lower")}); err == nil {
+		t.Error("invalid FAT32 label accepted")
+	}
+
+	flags := []CreateFlag{CreateFAT32, CreateVolname("lower"), CreateNormalizeLabel}
+	got, err := checkVolumeLabel(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := got[1].(CreateVolname); v != "LOWER" {
+		t.Errorf("normalized volname %q, want LOWER", v)
+	}
+	if flags[1].(CreateVolname) != "lower" {
+		t.Error("flags of the caller modified")
+	}
+}